@@ -0,0 +1,203 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const batesCmdDesc = `Stamp sequential Bates numbers on the pages of PDF files.
+
+A Bates number consists of a prefix, a zero-padded counter and a suffix
+(e.g. ABC000123-CONF). The prefix and the suffix are specified using the
+--prefix and --suffix flags, while the minimum width of the counter is
+specified using the --digits flag (default 6).
+
+The command can take multiple files and directories as input parameters.
+The files are numbered in the order in which they are specified and the
+numbering continues from file to file, starting with the value specified
+by the --start flag (default 1). The files inside the input directories are
+numbered in the alphabetical order of their names, and the files inside
+subdirectories are numbered when the subdirectory is reached in that order.
+The command can search for PDF files inside the subdirectories of the
+specified input directories by using the --recursive flag.
+
+By default, each PDF file is saved in the same location as the original file,
+appending the "_bates" suffix to the file name. Use the --overwrite flag
+to overwrite the original files.
+In addition, the output files can be saved to a different directory
+by using the --target-dir flag. If multiple input files have the same name,
+a numeric suffix is appended to the names of the output files following the
+first one (e.g. contract.pdf, contract_2.pdf), so that they do not overwrite
+each other.
+
+The numbered files can also be merged into a single volume, saved at the
+location specified by the --merge-file flag. The first and last Bates
+numbers of each file can be logged to a CSV file using the --log-file flag.
+
+Supported positions:
+  - top-left, top, top-right
+  - bottom-left, bottom, bottom-right (default)
+`
+
+var batesCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s bates --prefix ABC file_1.pdf file_n.pdf", appName),
+	fmt.Sprintf("%s bates --prefix ABC --start 1001 -d 8 -O file_1.pdf file_n.pdf", appName),
+	fmt.Sprintf("%s bates --prefix ABC --suffix -CONF -t out_dir -r dir_1 dir_n", appName),
+	fmt.Sprintf("%s bates --prefix ABC -t out_dir -l bates.csv -m volume.pdf file_1.pdf file_n.pdf", appName),
+	fmt.Sprintf("%s bates --prefix ABC --position bottom -s 8 -p pass file_1.pdf file_n.pdf", appName),
+)
+
+// batesCmd represents the bates command.
+var batesCmd = &cobra.Command{
+	Use:                   "bates [FLAG]... INPUT_FILES...",
+	Short:                 "Stamp Bates numbers on PDF files",
+	Long:                  batesCmdDesc,
+	Example:               batesCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse flags.
+		outputDir, _ := cmd.Flags().GetString("target-dir")
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		password, _ := cmd.Flags().GetString("password")
+		start, _ := cmd.Flags().GetInt("start")
+		logPath, _ := cmd.Flags().GetString("log-file")
+		mergePath, _ := cmd.Flags().GetString("merge-file")
+
+		opts := &pdf.BatesOpts{}
+		opts.Prefix, _ = cmd.Flags().GetString("prefix")
+		opts.Suffix, _ = cmd.Flags().GetString("suffix")
+		opts.Digits, _ = cmd.Flags().GetInt("digits")
+		opts.Position, _ = cmd.Flags().GetString("position")
+		opts.FontName, _ = cmd.Flags().GetString("font")
+		opts.FontPath, _ = cmd.Flags().GetString("font-file")
+		opts.FontSize, _ = cmd.Flags().GetFloat64("font-size")
+		opts.Color, _ = cmd.Flags().GetString("color")
+		margin, _ := cmd.Flags().GetFloat64("margin")
+		opts.Margin = &margin
+
+		if start < 0 {
+			printUsageErr(cmd, "The start value must not be negative\n")
+		}
+
+		// Parse input parameters.
+		inputPaths, err := parseInputPaths(args, recursive, pdfMatcher)
+		if err != nil {
+			printErr("Could not parse input files: %s\n", err)
+		}
+
+		// Create output directory, if it does not exist.
+		if outputDir != "" {
+			if overwrite {
+				printErr("The --target-dir and the --overwrite flags are mutually exclusive")
+			}
+
+			if err = os.MkdirAll(outputDir, os.ModePerm); err != nil {
+				printErr("Could not create output directory: %s\n", err)
+			}
+		}
+
+		// Number PDF files.
+		logRecords := [][]string{
+			{"input_file", "output_file", "begin", "end", "pages"},
+		}
+
+		var outputPaths []string
+		usedPaths := map[string]bool{}
+		for _, inputPath := range inputPaths {
+			fmt.Printf("Numbering %s\n", inputPath)
+
+			// Generate output path.
+			outputPath := generateOutputPath(inputPath, outputDir, "bates", overwrite)
+			outputPath = uniqueOutputPath(outputPath, usedPaths)
+
+			// Stamp Bates numbers.
+			res, err := pdf.Bates(inputPath, outputPath, password, start, opts)
+			if err != nil {
+				printErr("Could not stamp Bates numbers on input file: %s\n", err)
+			}
+			start = res.Next
+
+			outputPaths = append(outputPaths, outputPath)
+			logRecords = append(logRecords, []string{
+				inputPath, outputPath, res.Begin, res.End, strconv.Itoa(res.Pages),
+			})
+
+			fmt.Printf("Output file: %s\n", outputPath)
+			fmt.Printf("Bates numbers: %s - %s\n", res.Begin, res.End)
+		}
+
+		// Write log file.
+		if logPath != "" {
+			if err := writeCSVFile(logPath, logRecords); err != nil {
+				printErr("Could not write log file: %s\n", err)
+			}
+
+			fmt.Printf("Log file saved to %s\n", logPath)
+		}
+
+		// Merge output files.
+		if mergePath != "" {
+			if err := pdf.Merge(outputPaths, mergePath); err != nil {
+				printErr("Could not merge output files: %s\n", err)
+			}
+
+			fmt.Printf("Merged volume saved to %s\n", mergePath)
+		}
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("must provide at least one input file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(batesCmd)
+
+	batesCmd.Flags().StringP("target-dir", "t", "", "output directory")
+	batesCmd.Flags().BoolP("overwrite", "O", false, "overwrite input files")
+	batesCmd.Flags().BoolP("recursive", "r", false, "search PDF files in subdirectories")
+	batesCmd.Flags().StringP("password", "p", "", "file password")
+	batesCmd.Flags().String("prefix", "", "text placed before the counter")
+	batesCmd.Flags().String("suffix", "", "text placed after the counter")
+	batesCmd.Flags().IntP("digits", "d", 6, "minimum number of digits of the counter")
+	batesCmd.Flags().Int("start", 1, "start value of the counter")
+	batesCmd.Flags().String("position", "bottom-right", "position of the Bates numbers")
+	batesCmd.Flags().String("font", "Helvetica", "standard font used for the Bates numbers")
+	batesCmd.Flags().String("font-file", "", "TrueType font file used for the Bates numbers")
+	batesCmd.Flags().Float64P("font-size", "s", 10, "font size of the Bates numbers")
+	batesCmd.Flags().StringP("color", "c", "#000000", "color of the Bates numbers")
+	batesCmd.Flags().Float64("margin", 36, "distance between the Bates numbers and the page edges")
+	batesCmd.Flags().StringP("log-file", "l", "", "CSV file logging the Bates numbers of each file")
+	batesCmd.Flags().StringP("merge-file", "m", "", "merge the output files into the specified file")
+}
+
+// uniqueOutputPath returns the provided output path, with a numeric suffix
+// appended to the file name if the path has already been used.
+func uniqueOutputPath(outputPath string, usedPaths map[string]bool) string {
+	ext := filepath.Ext(outputPath)
+	name := strings.TrimSuffix(outputPath, ext)
+
+	path := outputPath
+	for i := 2; usedPaths[path]; i++ {
+		path = fmt.Sprintf("%s_%d%s", name, i, ext)
+	}
+	usedPaths[path] = true
+
+	return path
+}
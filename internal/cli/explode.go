@@ -16,8 +16,18 @@ import (
 const explodeCmdDesc = `Splits the input file into separate single page PDF files.
 
 The resulting PDF files are saved in a ZIP archive at the location specified
-by the --output-file parameter. If no output file is specified, the ZIP file
-is saved in the same directory as the input file.
+by the --output-file parameter. If the output file has the .tar, .tar.gz or
+.tgz extension, the files are saved in a TAR archive instead. If no output
+file is specified, the ZIP file is saved in the same directory as the input
+file. Alternatively, the files can be saved in a directory, specified using
+the --output-dir parameter.
+
+The names of the resulting files can be configured using the --name-template
+parameter (default "{name}_{page}.{ext}"). Supported placeholders:
+  - {name}: the name of the input file, without extension
+  - {page}: the page number
+  - {ext}: the file extension
+The page number supports a zero padded width (e.g. {page:04}).
 
 The command can be configured to extract only the specified pages using
 the --pages parameter.
@@ -27,11 +37,13 @@ Pages 1,2,3 (1-3), 4 and 6,7 (6-7) will be extracted, while page
 number 5 is skipped.
 `
 
-var explodeCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+var explodeCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n",
 	fmt.Sprintf("%s explode input_file.pdf", appName),
 	fmt.Sprintf("%s explode -o pages.zip input_file.pdf", appName),
 	fmt.Sprintf("%s explode -o pages.zip -P 1-3 input_file.pdf", appName),
 	fmt.Sprintf("%s explode -o pages.zip -P 1-3 -p pass input_file.pdf", appName),
+	fmt.Sprintf("%s explode -o pages.tar.gz input_file.pdf", appName),
+	fmt.Sprintf("%s explode -d pages_dir --name-template \"{name}-{page:04}.{ext}\" input_file.pdf", appName),
 )
 
 // explodeCmd represents the explode command.
@@ -46,6 +58,7 @@ var explodeCmd = &cobra.Command{
 		inputPath := args[0]
 		password, _ := cmd.Flags().GetString("password")
 		outputPath, _ := cmd.Flags().GetString("output-file")
+		outputOpts := parseOutputOpts(cmd, outputPath)
 
 		// Parse page range.
 		pageRange, _ := cmd.Flags().GetString("pages")
@@ -56,7 +69,7 @@ var explodeCmd = &cobra.Command{
 		}
 
 		// Explode file.
-		outputPath, err = pdf.Explode(inputPath, outputPath, password, pages)
+		outputPath, err = pdf.ExplodeWithOpts(inputPath, outputPath, password, pages, outputOpts)
 		if err != nil {
 			printErr("Could not explode input file: %s\n", err)
 			return
@@ -80,4 +93,6 @@ func init() {
 	explodeCmd.Flags().StringP("password", "p", "", "input file password")
 	explodeCmd.Flags().StringP("output-file", "o", "", "output file")
 	explodeCmd.Flags().StringP("pages", "P", "", "pages to extract from the input file")
+	explodeCmd.Flags().StringP("output-dir", "d", "", "output directory")
+	explodeCmd.Flags().String("name-template", "", "template used for naming the output files")
 }
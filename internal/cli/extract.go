@@ -9,7 +9,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
-const extractCmdDesc = `Extract PDF resources.`
+const extractCmdDesc = `Extract PDF resources.
+
+The commands which extract multiple files (fonts, images, tables and the text
+of the pages saved using the --split-pages flag) save them in a ZIP archive at
+the location specified by the --output-file parameter. If the output file has
+the .tar, .tar.gz or .tgz extension, the files are saved in a TAR archive
+instead. If no output file is specified, the ZIP archive is saved in the same
+directory as the input file. Alternatively, the files can be saved in a
+directory, specified using the --output-dir parameter.`
 
 // extractCmd represents the extract command.
 var extractCmd = &cobra.Command{
@@ -0,0 +1,119 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const extractAnnotationsCmdDesc = `Extracts PDF annotations.
+
+Each annotation (e.g. sticky note, highlight, free text comment) is exported
+along with its type, page number, rectangle, author, subject, creation and
+modification dates and contents. For text markup annotations (highlight,
+underline, strikeout and squiggly), the text covered by the annotation is
+also exported. Popup and form field annotations are skipped.
+
+By default, the annotations are printed to STDOUT. The output can be saved
+to a file using the --output-file parameter.
+
+The output format can be specified using the --format flag.
+Supported formats:
+  - json (default, replies are nested under the annotations they reply to)
+  - markdown (annotations grouped by page, replies are nested under the
+    annotations they reply to)
+  - csv (one row per annotation, replies reference the annotations they
+    reply to using the in_reply_to column)
+
+The command can be configured to extract annotations only from the specified
+pages using the --pages parameter.
+
+An example of the pages parameter: 1-3,4,6-7
+Annotations will only be extracted from pages 1,2,3 (1-3), 4 and 6,7 (6-7),
+while page number 5 is skipped.
+`
+
+var extractAnnotationsCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s extract annotations input_file.pdf", appName),
+	fmt.Sprintf("%s extract annotations -f markdown -o comments.md input_file.pdf", appName),
+	fmt.Sprintf("%s extract annotations -f csv -o comments.csv input_file.pdf", appName),
+	fmt.Sprintf("%s extract annotations -P 1-3 -p pass input_file.pdf", appName),
+)
+
+// extractAnnotationsCmd represents the extract annotations command.
+var extractAnnotationsCmd = &cobra.Command{
+	Use:                   "annotations [FLAG]... INPUT_FILE",
+	Short:                 "Extract PDF annotations",
+	Long:                  extractAnnotationsCmdDesc,
+	Example:               extractAnnotationsCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		format, _ := cmd.Flags().GetString("format")
+
+		switch format {
+		case "json", "markdown", "csv":
+		default:
+			printUsageErr(cmd, "Unsupported output format: %s\n", format)
+		}
+
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+
+		// Extract annotations.
+		annotations, err := pdf.ExtractAnnotations(inputPath, password, pages)
+		if err != nil {
+			printErr("Could not extract annotations: %s\n", err)
+		}
+
+		data, err := pdf.EncodeAnnotations(annotations, format)
+		if err != nil {
+			printErr("Could not encode annotations: %s\n", err)
+		}
+
+		// Write annotations.
+		if outputPath == "" {
+			fmt.Print(string(data))
+			return
+		}
+
+		// #nosec G306
+		if err := os.WriteFile(outputPath, data, 0644); err != nil {
+			printErr("Could not write annotations: %s\n", err)
+		}
+
+		fmt.Printf("%d annotations successfully extracted to %s\n", len(annotations), outputPath)
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("must provide the input file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	extractCmd.AddCommand(extractAnnotationsCmd)
+
+	extractAnnotationsCmd.Flags().StringP("password", "p", "", "input file password")
+	extractAnnotationsCmd.Flags().StringP("output-file", "o", "", "output file")
+	extractAnnotationsCmd.Flags().StringP("pages", "P", "", "pages to extract annotations from")
+	extractAnnotationsCmd.Flags().StringP("format", "f", "json", "output format (json, markdown, csv)")
+}
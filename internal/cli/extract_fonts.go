@@ -0,0 +1,125 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const extractFontsCmdDesc = `Extracts the embedded fonts of a PDF file.
+
+The embedded font programs are saved as separate files, based on their type:
+  - Type 1 fonts (FontFile) are saved as .pfb files
+  - TrueType fonts (FontFile2) are saved as .ttf files
+  - Compact (CFF) fonts (FontFile3) are saved as .cff files
+  - OpenType fonts (FontFile3) are saved as .otf files
+
+A fonts.json file is saved along with the font programs. The file contains
+the base font name, subset prefix, type, encoding and the numbers of the pages
+each font is used on, for all the fonts of the file, including the fonts
+which are not embedded. The fonts are also listed to STDOUT.
+
+The files are saved in an archive, or in the directory specified using the
+--output-dir parameter (see the help of the extract command).
+
+The command can be configured to extract fonts only from the specified pages
+using the --pages parameter.
+
+An example of the pages parameter: 1-3,4,6-7
+Fonts will only be extracted from pages 1,2,3 (1-3), 4 and 6,7 (6-7), while
+page number 5 is skipped.
+`
+
+var extractFontsCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s extract fonts input_file.pdf", appName),
+	fmt.Sprintf("%s extract fonts -o fonts.zip input_file.pdf", appName),
+	fmt.Sprintf("%s extract fonts -d fonts_dir input_file.pdf", appName),
+	fmt.Sprintf("%s extract fonts -P 1-3 -p pass -o fonts.zip input_file.pdf", appName),
+)
+
+// extractFontsCmd represents the extract fonts command.
+var extractFontsCmd = &cobra.Command{
+	Use:                   "fonts [FLAG]... INPUT_FILE",
+	Short:                 "Extract PDF fonts",
+	Long:                  extractFontsCmdDesc,
+	Example:               extractFontsCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		outputDir := parseOutputDir(cmd, outputPath)
+
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+
+		// Extract fonts.
+		outputPath, fonts, err := pdf.ExtractFonts(inputPath, outputPath, outputDir, password, pages)
+		if err != nil {
+			printErr("Could not extract fonts: %s\n", err)
+		}
+
+		if len(fonts) == 0 {
+			fmt.Printf("%s does not contain any fonts\n", inputPath)
+			return
+		}
+
+		// Print fonts.
+		for _, font := range fonts {
+			name := font.Name
+			if font.SubsetPrefix != "" {
+				name = font.SubsetPrefix + "+" + name
+			}
+
+			details := []string{font.Type}
+			if font.Encoding != "" {
+				details = append(details, font.Encoding)
+			}
+			if font.Embedded {
+				details = append(details, "embedded as "+font.File)
+			} else {
+				details = append(details, "not embedded")
+			}
+
+			pageNums := make([]string, 0, len(font.Pages))
+			for _, numPage := range font.Pages {
+				pageNums = append(pageNums, strconv.Itoa(numPage))
+			}
+
+			fmt.Printf("%s (%s), pages: %s\n", name, strings.Join(details, ", "), strings.Join(pageNums, ","))
+		}
+
+		fmt.Printf("Fonts successfully extracted to %s\n", outputPath)
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("must provide the input file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	extractCmd.AddCommand(extractFontsCmd)
+
+	extractFontsCmd.Flags().StringP("password", "p", "", "input file password")
+	extractFontsCmd.Flags().StringP("output-file", "o", "", "output file")
+	extractFontsCmd.Flags().StringP("output-dir", "d", "", "output directory")
+	extractFontsCmd.Flags().StringP("pages", "P", "", "pages to extract fonts from")
+}
@@ -11,15 +11,21 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/unidoc/unipdf-cli/pkg/pdf"
-
-	uniextractor "github.com/unidoc/unipdf/v4/extractor"
 )
 
 const extractImagesCmdDesc = `Extracts PDF images.
 
-The images are extracted in a ZIP file and saved at the destination specified
-by the --output-file parameter. If no output file is specified, the ZIP
-archive is saved in the same directory as the input file.
+The images are saved in an archive, or in the directory specified using the
+--output-dir parameter (see the help of the extract command).
+
+The names of the extracted images can be configured using the --name-template
+parameter (default "p{page}_{index}.{ext}"). Supported placeholders:
+  - {name}: the name of the input file, without extension
+  - {page}: the page number
+  - {index}: the index of the image on the page
+  - {ext}: the file extension
+The page number and the image index support a zero padded width
+(e.g. {page:04}).
 
 The command can be configured to extract images only from the specified
 pages using the --pages parameter.
@@ -27,13 +33,46 @@ pages using the --pages parameter.
 An example of the pages parameter: 1-3,4,6-7
 Images will only be extracted from pages 1,2,3 (1-3), 4 and 6,7 (6-7), while page
 number 5 is skipped.
+
+By default, the images are decoded and saved as JPEG files. The format of
+the saved images can be changed using the --format parameter.
+Supported formats:
+  - jpeg (default)
+  - png
+
+The --native flag can be used in order to save the images in their native
+format, whenever possible. DCT encoded images are saved as JPEG files and
+JPX encoded images are saved as JPEG 2000 files, using the original image
+data. All other images (including CCITT and JBIG2 encoded images) are
+decoded and saved losslessly as PNG files, unless a different format is
+specified using the --format parameter.
+
+The transparency of the images (soft masks and masks) is only preserved
+when the images are saved as PNG files. JPEG files, including the images
+saved in their native format, do not support transparency and are always
+opaque.
+
+Small images (e.g. icons) can be skipped using the --min-width and
+--min-height parameters, which specify the minimum size of the extracted
+images, in pixels. The --dedupe flag can be used in order to save identical
+images (e.g. a logo repeated on every page) only once.
+
+The --manifest flag can be used in order to save a manifest.json file along
+with the images. The manifest contains the file name, page number, position
+on the page, size in pixels, effective resolution, color space, bits per
+component and original filters of each extracted image.
 `
 
-var extractImagesCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+var extractImagesCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
 	fmt.Sprintf("%s extract images input_file.pdf", appName),
 	fmt.Sprintf("%s extract images -o images.zip input_file.pdf", appName),
 	fmt.Sprintf("%s extract images -P 1-3 -p pass -o images.zip input_file.pdf", appName),
 	fmt.Sprintf("%s extract images -P 1-3 -p pass -o images.zip -S input_file.pdf", appName),
+	fmt.Sprintf("%s extract images -f png -o images.zip input_file.pdf", appName),
+	fmt.Sprintf("%s extract images --native -o images.zip input_file.pdf", appName),
+	fmt.Sprintf("%s extract images --min-width 64 --min-height 64 --dedupe -o images.zip input_file.pdf", appName),
+	fmt.Sprintf("%s extract images --manifest -d images_dir input_file.pdf", appName),
+	fmt.Sprintf("%s extract images -d images_dir --name-template \"{name}-{page:04}-{index}.{ext}\" input_file.pdf", appName),
 )
 
 // extractImagesCmd represents the extract images command.
@@ -48,12 +87,30 @@ var extractImagesCmd = &cobra.Command{
 		inputPath := args[0]
 		password, _ := cmd.Flags().GetString("password")
 		outputPath, _ := cmd.Flags().GetString("output-file")
+		outputOpts := parseOutputOpts(cmd, outputPath)
 
 		// Parse image extraction options.
 		includeSM, _ := cmd.Flags().GetBool("include-inline-stencil-masks")
+		native, _ := cmd.Flags().GetBool("native")
+		format, _ := cmd.Flags().GetString("format")
+		minWidth, _ := cmd.Flags().GetInt("min-width")
+		minHeight, _ := cmd.Flags().GetInt("min-height")
+		dedupe, _ := cmd.Flags().GetBool("dedupe")
+		manifest, _ := cmd.Flags().GetBool("manifest")
+
+		if format != "" && format != "jpeg" && format != "png" {
+			printUsageErr(cmd, "Unsupported image format: %s\n", format)
+		}
 
-		extractOptions := &uniextractor.ImageExtractOptions{
+		extractOptions := &pdf.ExtractImagesOpts{
 			IncludeInlineStencilMasks: includeSM,
+			Native:                    native,
+			Format:                    format,
+			MinWidth:                  minWidth,
+			MinHeight:                 minHeight,
+			Dedupe:                    dedupe,
+			Manifest:                  manifest,
+			OutputOpts:                *outputOpts,
 		}
 
 		// Parse page range.
@@ -65,7 +122,7 @@ var extractImagesCmd = &cobra.Command{
 		}
 
 		// Extract images.
-		outputPath, count, err := pdf.ExtractImages(
+		outputPath, count, err := pdf.ExtractImagesWithOpts(
 			inputPath,
 			outputPath,
 			password,
@@ -98,5 +155,13 @@ func init() {
 	extractImagesCmd.Flags().StringP("password", "p", "", "input file password")
 	extractImagesCmd.Flags().StringP("output-file", "o", "", "output file")
 	extractImagesCmd.Flags().StringP("pages", "P", "", "pages to extract images from")
+	extractImagesCmd.Flags().StringP("output-dir", "d", "", "output directory")
+	extractImagesCmd.Flags().String("name-template", "", "template used for naming the output files")
 	extractImagesCmd.Flags().BoolP("include-inline-stencil-masks", "S", false, "include inline stencil masks")
+	extractImagesCmd.Flags().StringP("format", "f", "", "image format (jpeg, png)")
+	extractImagesCmd.Flags().Bool("native", false, "save images in their native format, when possible")
+	extractImagesCmd.Flags().Int("min-width", 0, "minimum image width in pixels")
+	extractImagesCmd.Flags().Int("min-height", 0, "minimum image height in pixels")
+	extractImagesCmd.Flags().Bool("dedupe", false, "save identical images only once")
+	extractImagesCmd.Flags().Bool("manifest", false, "save a manifest.json file describing the images")
 }
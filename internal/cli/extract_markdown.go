@@ -0,0 +1,104 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const extractMarkdownCmdDesc = `Converts PDF text to Markdown.
+
+By default, the resulting Markdown content is printed to STDOUT. The output
+can be saved to a file using the --output-file parameter.
+
+For tagged PDF files, the headings, paragraphs, lists and tables are read
+from the structure tree of the file. Use the --ignore-structure flag in order
+to infer the structure of the document from the layout of the text instead.
+For untagged files, headings are inferred from the document outline and from
+the size and weight of the fonts, paragraphs and lists are rebuilt from the
+geometry of the text lines and the detected tables are rendered as Markdown
+tables.
+
+The command can be configured to convert only the specified pages using
+the --pages parameter.
+
+An example of the pages parameter: 1-3,4,6-7
+Only pages 1,2,3 (1-3), 4 and 6,7 (6-7) will be converted, while page
+number 5 is skipped.
+`
+
+var extractMarkdownCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s extract markdown input_file.pdf", appName),
+	fmt.Sprintf("%s extract markdown -o output_file.md input_file.pdf", appName),
+	fmt.Sprintf("%s extract markdown -P 1-3 -p pass input_file.pdf", appName),
+	fmt.Sprintf("%s extract markdown --ignore-structure input_file.pdf", appName),
+)
+
+// extractMarkdownCmd represents the extract markdown command.
+var extractMarkdownCmd = &cobra.Command{
+	Use:                   "markdown [FLAG]... INPUT_FILE",
+	Short:                 "Convert PDF text to Markdown",
+	Long:                  extractMarkdownCmdDesc,
+	Example:               extractMarkdownCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+		outputPath, _ := cmd.Flags().GetString("output-file")
+
+		opts := &pdf.MarkdownOpts{}
+		opts.IgnoreStructure, _ = cmd.Flags().GetBool("ignore-structure")
+
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+
+		// Convert text.
+		md, err := pdf.ExtractMarkdown(inputPath, password, pages, opts)
+		if err != nil {
+			printErr("Could not convert text to Markdown: %s\n", err)
+		}
+
+		// Write Markdown content.
+		if outputPath == "" {
+			fmt.Print(md)
+			return
+		}
+
+		// #nosec G306
+		if err := os.WriteFile(outputPath, []byte(md), 0644); err != nil {
+			printErr("Could not write Markdown file: %s\n", err)
+		}
+
+		fmt.Printf("Markdown successfully extracted to %s\n", outputPath)
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("must provide the input file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	extractCmd.AddCommand(extractMarkdownCmd)
+
+	extractMarkdownCmd.Flags().StringP("password", "p", "", "input file password")
+	extractMarkdownCmd.Flags().StringP("output-file", "o", "", "output file")
+	extractMarkdownCmd.Flags().StringP("pages", "P", "", "pages to convert")
+	extractMarkdownCmd.Flags().Bool("ignore-structure", false, "ignore the structure tree of tagged files")
+}
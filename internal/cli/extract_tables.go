@@ -0,0 +1,103 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const extractTablesCmdDesc = `Extracts PDF tables.
+
+The tables are detected based on the position of the text on each page and
+are written as separate files, named after the page number and the index of
+the table on the page (e.g. p3_t1.csv).
+
+The output format of the tables can be specified using the --format flag.
+Supported formats:
+  - csv (default)
+  - json (each table is written as an array of rows, each row being an
+    array of cell texts)
+
+The tables are saved in an archive, or in the directory specified using the
+--output-dir parameter (see the help of the extract command).
+
+The command can be configured to extract tables only from the specified
+pages using the --pages parameter.
+
+An example of the pages parameter: 1-3,4,6-7
+Tables will only be extracted from pages 1,2,3 (1-3), 4 and 6,7 (6-7), while page
+number 5 is skipped.
+`
+
+var extractTablesCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s extract tables input_file.pdf", appName),
+	fmt.Sprintf("%s extract tables -o tables.zip input_file.pdf", appName),
+	fmt.Sprintf("%s extract tables -f json -d tables_dir input_file.pdf", appName),
+	fmt.Sprintf("%s extract tables -P 1-3 -p pass -o tables.zip input_file.pdf", appName),
+)
+
+// extractTablesCmd represents the extract tables command.
+var extractTablesCmd = &cobra.Command{
+	Use:                   "tables [FLAG]... INPUT_FILE",
+	Short:                 "Extract PDF tables",
+	Long:                  extractTablesCmdDesc,
+	Example:               extractTablesCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		outputDir := parseOutputDir(cmd, outputPath)
+		format, _ := cmd.Flags().GetString("format")
+
+		if format != "csv" && format != "json" {
+			printUsageErr(cmd, "Unsupported output format: %s\n", format)
+		}
+
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+
+		// Extract tables.
+		outputPath, count, err := pdf.ExtractTables(inputPath, outputPath, outputDir, password, pages, format)
+		if err != nil {
+			printErr("Could not extract tables: %s\n", err)
+			return
+		}
+
+		if count == 0 {
+			fmt.Printf("%s does not contain any tables to extract\n", inputPath)
+		} else {
+			fmt.Printf("%d tables successfully extracted to %s\n", count, outputPath)
+		}
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("must provide the input file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	extractCmd.AddCommand(extractTablesCmd)
+
+	extractTablesCmd.Flags().StringP("password", "p", "", "input file password")
+	extractTablesCmd.Flags().StringP("output-file", "o", "", "output file")
+	extractTablesCmd.Flags().StringP("output-dir", "d", "", "output directory")
+	extractTablesCmd.Flags().StringP("pages", "P", "", "pages to extract tables from")
+	extractTablesCmd.Flags().StringP("format", "f", "csv", "output format (csv, json)")
+}
@@ -6,8 +6,13 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/unidoc/unipdf-cli/pkg/pdf"
@@ -15,7 +20,8 @@ import (
 
 const extractTextCmdDesc = `Extracts PDF text.
 
-The extracted text is always printed to STDOUT.
+By default, the extracted text is printed to STDOUT. The output can be saved
+to a file using the --output-file parameter.
 
 The command can be configured to extract text only from the specified pages
 using the --pages parameter.
@@ -23,12 +29,47 @@ using the --pages parameter.
 An example of the pages parameter: 1-3,4,6-7
 Text will only be extracted from pages 1,2,3 (1-3), 4 and 6,7 (6-7), while page
 number 5 is skipped.
+
+The output format can be specified using the --format flag.
+Supported formats:
+  - text (default)
+  - json (the text marks and lines of each page, along with their bounding
+    boxes, fonts, font sizes and colors, in reading order)
+  - hocr (hOCR document containing the lines and the words of each page,
+    along with their bounding boxes)
+  - alto (ALTO XML document containing the lines and the words of each page,
+    along with their bounding boxes)
+
+The coordinates of the hOCR and ALTO output are expressed in points,
+relative to the top-left corner of the page. As the text is not the result
+of OCR, the word confidence is always set to the maximum value.
+
+The --layout flag can be used in order to preserve the physical layout
+(columns and indentation) of the pages in the plain text output.
+
+In the plain text output, the pages are separated by the text specified
+by the --page-separator flag (form feed by default). Escape sequences
+(e.g. \n, \f) are supported. Alternatively, the --split-pages flag can be
+used in order to save the text of each page as a separate file, named after
+the page number (e.g. p3.txt). The files are saved in an archive, or in the
+directory specified using the --output-dir parameter (see the help of the
+extract command).
+
+The pages which do not contain any text (e.g. scanned pages requiring OCR)
+are reported to STDERR.
 `
 
-var extractTextCmdExample = fmt.Sprintf("%s\n%s\n%s\n",
+var extractTextCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
 	fmt.Sprintf("%s extract text input_file.pdf", appName),
 	fmt.Sprintf("%s extract text -P 1-3 input_file.pdf", appName),
 	fmt.Sprintf("%s extract text -P 1-3 -p pass input_file.pdf", appName),
+	fmt.Sprintf("%s extract text --layout input_file.pdf", appName),
+	fmt.Sprintf("%s extract text -f json -P 1-3 input_file.pdf", appName),
+	fmt.Sprintf("%s extract text -f hocr -o output_file.hocr input_file.pdf", appName),
+	fmt.Sprintf("%s extract text -f alto -o output_file.xml input_file.pdf", appName),
+	fmt.Sprintf("%s extract text -o output_file.txt --page-separator \"\\n\\n\" input_file.pdf", appName),
+	fmt.Sprintf("%s extract text --split-pages -o pages.zip input_file.pdf", appName),
+	fmt.Sprintf("%s extract text --split-pages --layout -d pages_dir input_file.pdf", appName),
 )
 
 // extractTextCmd represents the extract text command.
@@ -42,6 +83,29 @@ var extractTextCmd = &cobra.Command{
 		// Parse input parameters.
 		inputPath := args[0]
 		password, _ := cmd.Flags().GetString("password")
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		format, _ := cmd.Flags().GetString("format")
+		layout, _ := cmd.Flags().GetBool("layout")
+		splitPages, _ := cmd.Flags().GetBool("split-pages")
+		outputDir := parseOutputDir(cmd, outputPath)
+
+		separator, _ := cmd.Flags().GetString("page-separator")
+		separator, err := strconv.Unquote(`"` + strings.ReplaceAll(separator, `"`, `\"`) + `"`)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page separator specified\n")
+		}
+
+		switch format {
+		case "text", "json", "hocr", "alto":
+		default:
+			printUsageErr(cmd, "Unsupported output format: %s\n", format)
+		}
+		if format != "text" && splitPages {
+			printUsageErr(cmd, "The --split-pages flag can only be used with the text format\n")
+		}
+		if outputDir != "" && !splitPages {
+			printUsageErr(cmd, "The --output-dir flag can only be used with the --split-pages flag\n")
+		}
 
 		// Parse page range.
 		pageRange, _ := cmd.Flags().GetString("pages")
@@ -52,12 +116,82 @@ var extractTextCmd = &cobra.Command{
 		}
 
 		// Extract text.
-		text, err := pdf.ExtractText(inputPath, password, pages)
+		pageTexts, err := pdf.ExtractTextPages(inputPath, password, pages)
 		if err != nil {
 			printErr("Could not extract text: %s\n", err)
 		}
 
-		fmt.Println(text)
+		// Report pages without text.
+		var emptyPages []string
+		for _, pageText := range pageTexts {
+			if !pageText.HasText() {
+				emptyPages = append(emptyPages, strconv.Itoa(pageText.Page))
+			}
+		}
+		if len(emptyPages) > 0 {
+			fmt.Fprintf(os.Stderr, "Pages without text: %s\n", strings.Join(emptyPages, ","))
+		}
+
+		// Write pages as separate files.
+		if splitPages {
+			if outputDir != "" {
+				outputPath = outputDir
+			} else if outputPath == "" {
+				dir, name := filepath.Split(inputPath)
+				name = strings.TrimSuffix(name, filepath.Ext(name)) + "_pages.zip"
+				outputPath = filepath.Join(dir, name)
+			}
+
+			if err := pdf.WriteTextPages(outputPath, outputDir, pageTexts, layout); err != nil {
+				printErr("Could not write extracted text: %s\n", err)
+			}
+
+			fmt.Printf("Text successfully extracted to %s\n", outputPath)
+			return
+		}
+
+		// Encode extracted text.
+		var output string
+		switch format {
+		case "text":
+			var sb strings.Builder
+			for _, pageText := range pageTexts {
+				sb.WriteString(pageText.PlainText(layout))
+				sb.WriteString(separator)
+			}
+			output = sb.String()
+		case "json":
+			data, err := json.MarshalIndent(pageTexts, "", "  ")
+			if err != nil {
+				printErr("Could not encode extracted text: %s\n", err)
+			}
+			output = string(data) + "\n"
+		case "hocr":
+			data, err := pdf.EncodeHOCR(pageTexts, filepath.Base(inputPath))
+			if err != nil {
+				printErr("Could not encode extracted text: %s\n", err)
+			}
+			output = string(data)
+		case "alto":
+			data, err := pdf.EncodeALTO(pageTexts, filepath.Base(inputPath))
+			if err != nil {
+				printErr("Could not encode extracted text: %s\n", err)
+			}
+			output = string(data)
+		}
+
+		// Write extracted text.
+		if outputPath == "" {
+			fmt.Print(output)
+			return
+		}
+
+		// #nosec G306
+		if err := os.WriteFile(outputPath, []byte(output), 0644); err != nil {
+			printErr("Could not write extracted text: %s\n", err)
+		}
+
+		fmt.Printf("Text successfully extracted to %s\n", outputPath)
 	},
 	Args: func(_ *cobra.Command, args []string) error {
 		if len(args) < 1 {
@@ -73,4 +207,10 @@ func init() {
 
 	extractTextCmd.Flags().StringP("password", "p", "", "input file password")
 	extractTextCmd.Flags().StringP("pages", "P", "", "pages to extract text from")
+	extractTextCmd.Flags().StringP("output-file", "o", "", "output file")
+	extractTextCmd.Flags().StringP("output-dir", "d", "", "output directory of the --split-pages flag")
+	extractTextCmd.Flags().StringP("format", "f", "text", "output format (text, json, hocr, alto)")
+	extractTextCmd.Flags().BoolP("layout", "l", false, "preserve the physical layout of the pages")
+	extractTextCmd.Flags().String("page-separator", `\f`, "text inserted after each page")
+	extractTextCmd.Flags().BoolP("split-pages", "s", false, "save the text of each page as a separate file")
 }
@@ -0,0 +1,23 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const indexCmdDesc = `Full-text index operations.`
+
+// indexCmd represents the index command.
+var indexCmd = &cobra.Command{
+	Use:   "index [FLAG]... COMMAND",
+	Short: "Full-text index operations",
+	Long:  indexCmdDesc,
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+}
@@ -0,0 +1,112 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const indexBuildCmdDesc = `Builds or updates a full-text index of PDF files.
+
+The text of the input files is extracted and stored in an on-disk inverted
+index, located in the directory specified by the --index flag. The index
+contains the positions of the words on each page of the files and can be
+searched using the index search command, without extracting the text of the
+files again.
+
+The index is updated incrementally. If the index already exists, only the
+new files and the files which changed since they were indexed (based on
+their modification time, size and content hash) are processed. The indexed
+files which no longer exist are removed from the index.
+
+The command can take multiple files and directories as input parameters.
+The command can search for PDF files inside the subdirectories of the
+specified input directories by using the --recursive flag. The text of the
+files is extracted in parallel. The number of files processed at the same
+time can be specified using the --jobs flag (defaults to the number of CPUs).
+`
+
+var indexBuildCmdExample = fmt.Sprintf("%s\n%s\n%s\n",
+	fmt.Sprintf("%s index build -i index_dir file_1.pdf file_n.pdf", appName),
+	fmt.Sprintf("%s index build -i index_dir -r dir_1 dir_n", appName),
+	fmt.Sprintf("%s index build -i index_dir -r -j 4 -p pass dir_1 dir_n", appName),
+)
+
+// indexBuildCmd represents the index build command.
+var indexBuildCmd = &cobra.Command{
+	Use:                   "build [FLAG]... INPUT_FILES...",
+	Short:                 "Build a full-text index",
+	Long:                  indexBuildCmdDesc,
+	Example:               indexBuildCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse flags.
+		indexDir, _ := cmd.Flags().GetString("index")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		password, _ := cmd.Flags().GetString("password")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+
+		if indexDir == "" {
+			printUsageErr(cmd, "Must provide the index directory\n")
+		}
+
+		// Parse input parameters.
+		inputPaths, err := parseInputPaths(args, recursive, pdfMatcher)
+		if err != nil {
+			printErr("Could not parse input files: %s\n", err)
+		}
+
+		// Build index.
+		fmt.Printf("Indexing %d files\n", len(inputPaths))
+
+		res, err := pdf.BuildIndex(indexDir, inputPaths, password, jobs)
+		if err != nil {
+			printErr("Could not build index: %s\n", err)
+		}
+
+		failed := make([]string, 0, len(res.Failed))
+		for inputPath := range res.Failed {
+			failed = append(failed, inputPath)
+		}
+		sort.Strings(failed)
+
+		for _, inputPath := range failed {
+			fmt.Fprintf(os.Stderr, "Could not index %s: %s\n", inputPath, res.Failed[inputPath])
+		}
+
+		fmt.Printf("Added: %d\n", len(res.Added))
+		fmt.Printf("Updated: %d\n", len(res.Updated))
+		fmt.Printf("Removed: %d\n", len(res.Removed))
+		fmt.Printf("Unchanged: %d\n", len(res.Unchanged))
+		fmt.Printf("Failed: %d\n", len(res.Failed))
+
+		if len(failed) > 0 {
+			os.Exit(1)
+		}
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("must provide at least one input file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	indexCmd.AddCommand(indexBuildCmd)
+
+	indexBuildCmd.Flags().StringP("index", "i", "", "index directory")
+	indexBuildCmd.Flags().BoolP("recursive", "r", false, "search PDF files in subdirectories")
+	indexBuildCmd.Flags().StringP("password", "p", "", "input file password")
+	indexBuildCmd.Flags().IntP("jobs", "j", 0, "number of files processed in parallel (default number of CPUs)")
+}
@@ -0,0 +1,108 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const indexSearchCmdDesc = `Searches a full-text index of PDF files.
+
+The index must be created using the index build command. The pages matching
+the query are printed in order of relevance, in the file:page: snippet
+format.
+
+The query consists of words and of phrases enclosed in double quotes. The
+words are matched case insensitively, while the words of the phrases must
+appear consecutively. A page matches the query if it contains all its words
+and phrases.
+
+The maximum number of results can be specified using the --limit flag and
+the number of characters of surrounding text included in the snippets can
+be specified using the --context flag.
+
+The output format can be specified using the --format flag.
+Supported formats:
+  - text (default)
+  - json (the results, along with their relevance scores and the number of
+    occurrences of the query words on each page)
+`
+
+var indexSearchCmdExample = fmt.Sprintf("%s\n%s\n%s\n",
+	fmt.Sprintf("%s index search index_dir \"invoice\"", appName),
+	fmt.Sprintf("%s index search index_dir '\"purchase order\" invoice'", appName),
+	fmt.Sprintf("%s index search -n 100 -C 60 -f json index_dir \"invoice\"", appName),
+)
+
+// indexSearchCmd represents the index search command.
+var indexSearchCmd = &cobra.Command{
+	Use:                   "search [FLAG]... INDEX_DIR QUERY",
+	Short:                 "Search a full-text index",
+	Long:                  indexSearchCmdDesc,
+	Example:               indexSearchCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		indexDir := args[0]
+		query := args[1]
+		limit, _ := cmd.Flags().GetInt("limit")
+		context, _ := cmd.Flags().GetInt("context")
+
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			printUsageErr(cmd, "Unsupported output format: %s\n", format)
+		}
+		if context < 0 {
+			printUsageErr(cmd, "The context must not be negative\n")
+		}
+
+		// Search index.
+		hits, err := pdf.SearchIndex(indexDir, query, limit, context)
+		if err != nil {
+			printErr("Could not search index: %s\n", err)
+		}
+
+		// Print results.
+		if format == "json" {
+			data, err := json.MarshalIndent(hits, "", "  ")
+			if err != nil {
+				printErr("Could not encode search results: %s\n", err)
+			}
+
+			fmt.Println(string(data))
+			return
+		}
+
+		for _, hit := range hits {
+			if hit.Snippet == "" {
+				fmt.Printf("%s:%d\n", hit.File, hit.Page)
+				continue
+			}
+
+			fmt.Printf("%s:%d: %s\n", hit.File, hit.Page, hit.Snippet)
+		}
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("must provide the index directory and the query")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	indexCmd.AddCommand(indexSearchCmd)
+
+	indexSearchCmd.Flags().IntP("limit", "n", 20, "maximum number of results (0 for no limit)")
+	indexSearchCmd.Flags().IntP("context", "C", 40, "number of context characters included in snippets")
+	indexSearchCmd.Flags().StringP("format", "f", "text", "output format (text, json)")
+}
@@ -0,0 +1,118 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const overlayCmdDesc = `Overlay or underlay the pages of a template PDF file on PDF files.
+
+The pages of the template file (e.g. letterhead or stationery) are imported
+as vector graphics and drawn over the page contents of the input file. Use
+the --under flag in order to draw the template pages under the page contents.
+
+By default, the first page of the template file is applied to all the pages
+of the input file. The template pages to use can be specified using the
+--template-pages flag. The template pages are applied in order, the last
+template page being used for all the remaining pages of the input file.
+Use the --cycle flag in order to repeat the template pages cyclically.
+A different template page can be applied to the first page of the input
+file using the --first-page flag.
+
+The command can be configured to apply the template only to the specified
+pages using the --pages parameter.
+
+An example of the pages parameter: 1-3,4,6-7
+The template will only be applied to pages 1,2,3 (1-3), 4 and 6,7 (6-7), while
+page number 5 is skipped.
+`
+
+var overlayCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s overlay stamp.pdf input_file.pdf", appName),
+	fmt.Sprintf("%s overlay --under -o output_file.pdf stationery.pdf input_file.pdf", appName),
+	fmt.Sprintf("%s overlay --under --first-page 1 --template-pages 2 -o output_file.pdf stationery.pdf input_file.pdf", appName),
+	fmt.Sprintf("%s overlay --template-pages 1-2 --cycle -P 1-10 -o output_file.pdf template.pdf input_file.pdf", appName),
+	fmt.Sprintf("%s overlay --over -p pass --template-password tpl_pass -o output_file.pdf template.pdf input_file.pdf", appName),
+)
+
+// overlayCmd represents the overlay command.
+var overlayCmd = &cobra.Command{
+	Use:                   "overlay [FLAG]... TEMPLATE_FILE INPUT_FILE",
+	Short:                 "Overlay template pages on PDF files",
+	Long:                  overlayCmdDesc,
+	Example:               overlayCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[1]
+		password, _ := cmd.Flags().GetString("password")
+
+		// Parse output file.
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		if outputPath == "" {
+			outputPath = inputPath
+		}
+
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+
+		// Parse overlay options.
+		opts := &pdf.OverlayOpts{TemplatePath: args[0]}
+		opts.TemplatePassword, _ = cmd.Flags().GetString("template-password")
+		opts.FirstPage, _ = cmd.Flags().GetInt("first-page")
+		opts.Cycle, _ = cmd.Flags().GetBool("cycle")
+		opts.Under, _ = cmd.Flags().GetBool("under")
+
+		over, _ := cmd.Flags().GetBool("over")
+		if over && opts.Under {
+			printUsageErr(cmd, "The --over and the --under flags are mutually exclusive\n")
+		}
+
+		templateRange, _ := cmd.Flags().GetString("template-pages")
+		if opts.TemplatePages, err = parsePageRangeUnsorted(templateRange); err != nil {
+			printUsageErr(cmd, "Invalid template page range specified\n")
+		}
+
+		// Apply template.
+		if err := pdf.Overlay(inputPath, outputPath, password, pages, opts); err != nil {
+			printErr("Could not apply template to the input file: %s\n", err)
+		}
+
+		fmt.Printf("Template successfully applied to %s\n", inputPath)
+		fmt.Printf("Output file saved to %s\n", outputPath)
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("must provide the template file and the input file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(overlayCmd)
+
+	overlayCmd.Flags().StringP("output-file", "o", "", "output file")
+	overlayCmd.Flags().StringP("password", "p", "", "input file password")
+	overlayCmd.Flags().StringP("pages", "P", "", "pages to apply the template to")
+	overlayCmd.Flags().String("template-password", "", "template file password")
+	overlayCmd.Flags().StringP("template-pages", "T", "", "template pages to apply, in order")
+	overlayCmd.Flags().Int("first-page", 0, "template page applied to the first page")
+	overlayCmd.Flags().Bool("cycle", false, "repeat the template pages cyclically")
+	overlayCmd.Flags().Bool("over", false, "draw the template over the page contents (default)")
+	overlayCmd.Flags().Bool("under", false, "draw the template under the page contents")
+}
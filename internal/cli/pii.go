@@ -0,0 +1,72 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const piiCmdDesc = `Personally identifiable information (PII) operations.`
+
+// piiCmd represents the pii command.
+var piiCmd = &cobra.Command{
+	Use:   "pii [FLAG]... COMMAND",
+	Short: "PII detection and redaction",
+	Long:  piiCmdDesc,
+}
+
+func init() {
+	rootCmd.AddCommand(piiCmd)
+}
+
+// addPIIDetectorFlags adds the flags used for configuring the PII detectors
+// to the specified command.
+func addPIIDetectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("types", "t", "", "comma-separated built-in PII types to detect, or none (default all)")
+	cmd.Flags().StringArray("pattern", nil, "custom PII pattern (NAME=REGEX)")
+}
+
+// parsePIIDetectors returns the PII detectors specified by the flags of the
+// provided command.
+func parsePIIDetectors(cmd *cobra.Command) ([]*pdf.PIIDetector, error) {
+	types, _ := cmd.Flags().GetString("types")
+	patterns, _ := cmd.Flags().GetStringArray("pattern")
+
+	var detectors []*pdf.PIIDetector
+	if types != "none" {
+		var typeList []string
+		for _, typ := range strings.Split(types, ",") {
+			if typ = strings.TrimSpace(typ); typ != "" {
+				typeList = append(typeList, typ)
+			}
+		}
+
+		builtin, err := pdf.NewPIIDetectors(typeList)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, builtin...)
+	}
+
+	for _, pattern := range patterns {
+		parts := strings.SplitN(pattern, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.New("custom patterns must be specified as NAME=REGEX")
+		}
+
+		detectors = append(detectors, &pdf.PIIDetector{Type: parts[0], Pattern: parts[1]})
+	}
+
+	if len(detectors) == 0 {
+		return nil, errors.New("no PII types specified")
+	}
+
+	return detectors, nil
+}
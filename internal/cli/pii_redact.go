@@ -0,0 +1,98 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const piiRedactCmdDesc = `Redacts personally identifiable information (PII) from PDF files.
+
+The detected information is redacted in the same way as by the redact
+command: the text is removed from the content streams of the pages, the
+pixels of the images overlapping it are blanked out and boxes are drawn over
+it. The information is also removed from the annotations and from the
+metadata of the file.
+
+The detected PII types are configured in the same way as for the pii scan
+command, using the --types and --pattern flags. It is recommended to review
+the findings using the pii scan command before redacting them.
+
+If redacted information can still be extracted from the output file, a
+warning is printed and the command exits with a non-zero status.
+
+If no output file is specified, the output file is saved next to the input
+file, with the _redacted suffix.
+`
+
+var piiRedactCmdExample = fmt.Sprintf("%s\n%s\n%s\n",
+	fmt.Sprintf("%s pii redact input_file.pdf", appName),
+	fmt.Sprintf("%s pii redact -o output_file.pdf -t ssn,credit-card --overlay-text REDACTED input_file.pdf", appName),
+	fmt.Sprintf("%s pii redact -o output_file.pdf -t none --pattern \"employee-id=EMP-[0-9]{6}\" -p pass input_file.pdf", appName),
+)
+
+// piiRedactCmd represents the pii redact command.
+var piiRedactCmd = &cobra.Command{
+	Use:                   "redact [FLAG]... INPUT_FILE",
+	Short:                 "Redact PII from PDF files",
+	Long:                  piiRedactCmdDesc,
+	Example:               piiRedactCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+
+		detectors, err := parsePIIDetectors(cmd)
+		if err != nil {
+			printUsageErr(cmd, "%s\n", err)
+		}
+
+		opts := &pdf.RedactOpts{Detectors: detectors}
+		opts.Color, _ = cmd.Flags().GetString("color")
+		opts.OverlayText, _ = cmd.Flags().GetString("overlay-text")
+
+		// Parse output file.
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		if outputPath == "" {
+			outputPath = generateOutputPath(inputPath, "", "redacted", false)
+		}
+
+		// Redact file.
+		results, err := pdf.Redact(inputPath, outputPath, password, opts)
+		if err != nil {
+			printErr("Could not redact PII: %s\n", err)
+		}
+
+		remaining := printRedactResults(results)
+		fmt.Printf("Output file saved to %s\n", outputPath)
+		if remaining > 0 {
+			os.Exit(1)
+		}
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("must provide a PDF file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	piiCmd.AddCommand(piiRedactCmd)
+
+	piiRedactCmd.Flags().StringP("output-file", "o", "", "output file")
+	piiRedactCmd.Flags().StringP("password", "p", "", "input file password")
+	piiRedactCmd.Flags().StringP("color", "c", "#000000", "color of the redaction boxes")
+	piiRedactCmd.Flags().String("overlay-text", "", "text drawn over the redaction boxes")
+	addPIIDetectorFlags(piiRedactCmd)
+}
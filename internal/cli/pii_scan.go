@@ -0,0 +1,126 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const piiScanCmdDesc = `Scans PDF files for personally identifiable information (PII).
+
+The findings are reported as JSON, grouped by page. Each finding contains
+its type, the found text, its position in the text of the page and its
+bounding boxes. By default, the report is printed to STDOUT. The report can
+be saved to a file using the --output-file parameter.
+
+Built-in PII types:
+  - email: email addresses
+  - credit-card: payment card numbers, validated using the Luhn algorithm
+  - iban: international bank account numbers, validated using their check
+    digits
+  - ssn: US Social Security numbers
+  - phone: phone numbers, including the area code
+
+By default, all the built-in types are detected. The detected types can be
+specified using the --types flag. Custom patterns can be added using the
+--pattern flag, which can be used multiple times. The built-in types can be
+disabled using --types none, in order to only detect custom patterns.
+
+The command can be configured to scan only the specified pages using the
+--pages parameter.
+
+An example of the pages parameter: 1-3,4,6-7
+Only pages 1,2,3 (1-3), 4 and 6,7 (6-7) will be scanned, while page number 5
+is skipped.
+`
+
+var piiScanCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s pii scan input_file.pdf", appName),
+	fmt.Sprintf("%s pii scan -o report.json -t email,phone input_file.pdf", appName),
+	fmt.Sprintf("%s pii scan --pattern \"employee-id=EMP-[0-9]{6}\" input_file.pdf", appName),
+	fmt.Sprintf("%s pii scan -P 1-3 -p pass input_file.pdf", appName),
+)
+
+// piiScanCmd represents the pii scan command.
+var piiScanCmd = &cobra.Command{
+	Use:                   "scan [FLAG]... INPUT_FILE",
+	Short:                 "Scan PDF files for PII",
+	Long:                  piiScanCmdDesc,
+	Example:               piiScanCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+		outputPath, _ := cmd.Flags().GetString("output-file")
+
+		detectors, err := parsePIIDetectors(cmd)
+		if err != nil {
+			printUsageErr(cmd, "%s\n", err)
+		}
+
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+
+		// Scan file.
+		results, err := pdf.ScanPII(inputPath, password, detectors, pages)
+		if err != nil {
+			printErr("Could not scan file: %s\n", err)
+		}
+		if results == nil {
+			results = []*pdf.PIIScanResult{}
+		}
+
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			printErr("Could not encode scan results: %s\n", err)
+		}
+
+		// Write results.
+		if outputPath == "" {
+			fmt.Println(string(data))
+			return
+		}
+
+		// #nosec G306
+		if err := os.WriteFile(outputPath, append(data, '\n'), 0644); err != nil {
+			printErr("Could not write scan results: %s\n", err)
+		}
+
+		var findings int
+		for _, result := range results {
+			findings += len(result.Findings)
+		}
+		fmt.Printf("%d findings on %d pages saved to %s\n", findings, len(results), outputPath)
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("must provide a PDF file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	piiCmd.AddCommand(piiScanCmd)
+
+	piiScanCmd.Flags().StringP("password", "p", "", "input file password")
+	piiScanCmd.Flags().StringP("output-file", "o", "", "output file")
+	piiScanCmd.Flags().StringP("pages", "P", "", "pages to scan")
+	addPIIDetectorFlags(piiScanCmd)
+}
@@ -0,0 +1,189 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const redactCmdDesc = `Redact text and areas of PDF files.
+
+The content to redact can be specified using the following flags, each of
+which can be used multiple times:
+  - --text: text to redact. The search is case sensitive, unless the
+    --ignore-case flag is used.
+  - --regex: regular expression matching the text to redact
+  - --area: page area to redact, in the PAGE:X,Y,WIDTH,HEIGHT format.
+    The coordinates are specified in points, with the origin in the
+    bottom left corner of the page.
+
+The redacted text is removed from the content streams of the pages, not just
+hidden, so it cannot be recovered by selecting or extracting the text. The
+pixels of the images overlapping the redacted content are blanked out and
+boxes are drawn over the redacted content. The color of the boxes can be
+changed using the --color flag and a text can be drawn over the boxes using
+the --overlay-text flag.
+
+Annotations overlapping the redacted content are removed, and the redacted
+text is also removed from the text of the remaining annotations, from the
+values of the form fields, from the bookmarks and from the metadata of the
+file, where it is replaced by the overlay text. The values of the form fields
+overlapping the redacted areas are removed. The appearances of the changed
+form fields are removed, so that PDF viewers regenerate them.
+
+If redacted text can still be extracted from the output file (e.g. text
+drawn using unsupported fonts), a warning is printed and the command exits
+with a non-zero status.
+
+If no output file is specified, the output file is saved next to the input
+file, with the _redacted suffix.
+`
+
+var redactCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s redact -t \"John Smith\" input_file.pdf", appName),
+	fmt.Sprintf("%s redact -o output_file.pdf -i -t \"john smith\" -t \"jane doe\" input_file.pdf", appName),
+	fmt.Sprintf("%s redact -o output_file.pdf -E \"[0-9]{3}-[0-9]{2}-[0-9]{4}\" --overlay-text REDACTED input_file.pdf", appName),
+	fmt.Sprintf("%s redact -o output_file.pdf -a 1:72,600,200,50 -c \"#ffffff\" -p pass input_file.pdf", appName),
+)
+
+// redactCmd represents the redact command.
+var redactCmd = &cobra.Command{
+	Use:                   "redact [FLAG]... INPUT_FILE",
+	Short:                 "Redact text and areas of PDF files",
+	Long:                  redactCmdDesc,
+	Example:               redactCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+
+		opts := &pdf.RedactOpts{}
+		opts.Texts, _ = cmd.Flags().GetStringArray("text")
+		opts.Regexes, _ = cmd.Flags().GetStringArray("regex")
+		opts.IgnoreCase, _ = cmd.Flags().GetBool("ignore-case")
+		opts.Color, _ = cmd.Flags().GetString("color")
+		opts.OverlayText, _ = cmd.Flags().GetString("overlay-text")
+
+		// Parse redaction areas.
+		areas, _ := cmd.Flags().GetStringArray("area")
+		for _, area := range areas {
+			redactArea, err := parseRedactArea(area)
+			if err != nil {
+				printUsageErr(cmd, "Invalid redaction area %s: %s\n", area, err)
+			}
+			opts.Areas = append(opts.Areas, redactArea)
+		}
+
+		if len(opts.Texts)+len(opts.Regexes)+len(opts.Areas) == 0 {
+			printUsageErr(cmd, "Must provide the text or the areas to redact\n")
+		}
+
+		// Parse output file.
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		if outputPath == "" {
+			outputPath = generateOutputPath(inputPath, "", "redacted", false)
+		}
+
+		// Redact file.
+		results, err := pdf.Redact(inputPath, outputPath, password, opts)
+		if err != nil {
+			printErr("Could not redact the specified content: %s\n", err)
+		}
+
+		remaining := printRedactResults(results)
+		fmt.Printf("Output file saved to %s\n", outputPath)
+		if remaining > 0 {
+			os.Exit(1)
+		}
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("must provide a PDF file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(redactCmd)
+
+	redactCmd.Flags().StringP("output-file", "o", "", "output file")
+	redactCmd.Flags().StringP("password", "p", "", "input file password")
+	redactCmd.Flags().StringArrayP("text", "t", nil, "text to redact")
+	redactCmd.Flags().StringArrayP("regex", "E", nil, "regular expression matching the text to redact")
+	redactCmd.Flags().StringArrayP("area", "a", nil, "page area to redact (PAGE:X,Y,WIDTH,HEIGHT)")
+	redactCmd.Flags().BoolP("ignore-case", "i", false, "perform a case insensitive search")
+	redactCmd.Flags().StringP("color", "c", "#000000", "color of the redaction boxes")
+	redactCmd.Flags().String("overlay-text", "", "text drawn over the redaction boxes")
+}
+
+// printRedactResults prints the redactions made on each page and outside of
+// the pages. Returns the number of occurrences of redacted content which
+// could not be removed.
+func printRedactResults(results []*pdf.RedactResult) int {
+	var remaining int
+	for _, result := range results {
+		location := fmt.Sprintf("page %d", result.Page)
+		if result.Page == 0 {
+			location = "document"
+			fmt.Printf("Document: %d matches redacted in form fields, bookmarks and metadata\n", result.Matches)
+		} else {
+			fmt.Printf("Page %d: %d matches, %d glyphs, %d images, %d annotations redacted\n",
+				result.Page, result.Matches, result.Glyphs, result.Images, result.Annotations)
+		}
+
+		if result.Remaining > 0 {
+			remaining += result.Remaining
+			fmt.Fprintf(os.Stderr, "Warning: %s: %d occurrences of redacted content could not be removed\n",
+				location, result.Remaining)
+		}
+	}
+
+	return remaining
+}
+
+// parseRedactArea parses a redaction area in the PAGE:X,Y,WIDTH,HEIGHT
+// format.
+func parseRedactArea(area string) (*pdf.RedactArea, error) {
+	parts := strings.SplitN(area, ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("expected PAGE:X,Y,WIDTH,HEIGHT")
+	}
+
+	page, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || page < 1 {
+		return nil, fmt.Errorf("invalid page number: %s", parts[0])
+	}
+
+	coords := strings.Split(parts[1], ",")
+	if len(coords) != 4 {
+		return nil, errors.New("expected PAGE:X,Y,WIDTH,HEIGHT")
+	}
+
+	var vals [4]float64
+	for i, coord := range coords {
+		if vals[i], err = strconv.ParseFloat(strings.TrimSpace(coord), 64); err != nil {
+			return nil, fmt.Errorf("invalid coordinate: %s", coord)
+		}
+	}
+	if vals[2] <= 0 || vals[3] <= 0 {
+		return nil, errors.New("width and height must be positive")
+	}
+
+	return &pdf.RedactArea{
+		Page: page,
+		Rect: pdf.Rect{Llx: vals[0], Lly: vals[1], Urx: vals[0] + vals[2], Ury: vals[1] + vals[3]},
+	}, nil
+}
@@ -16,8 +16,19 @@ import (
 const renderCmdDesc = `Renders the pages of the input file to image targets.
 
 The rendered image files are saved in a ZIP archive at the location specified
-by the --output-file parameter. If no output file is specified, the ZIP file
-is saved in the same directory as the input file.
+by the --output-file parameter. If the output file has the .tar, .tar.gz or
+.tgz extension, the files are saved in a TAR archive instead. If no output
+file is specified, the ZIP file is saved in the same directory as the input
+file. Alternatively, the files can be saved in a directory, specified using
+the --output-dir parameter.
+
+The names of the rendered image files can be configured using the
+--name-template parameter (default "{name}_{page}.{ext}"). Supported
+placeholders:
+  - {name}: the name of the input file, without extension
+  - {page}: the page number
+  - {ext}: the file extension
+The page number supports a zero padded width (e.g. {page:04}).
 
 The command can be configured to render only the specified pages using
 the --pages parameter.
@@ -37,12 +48,14 @@ The quality of the rendered image files can be configured through
 the --image-quality flag (default 100). Only applies to JPEG images.
 `
 
-var renderCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n",
+var renderCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
 	fmt.Sprintf("%s render input_file.pdf", appName),
 	fmt.Sprintf("%s render -o images.zip input_file.pdf", appName),
 	fmt.Sprintf("%s render -o images.zip -P 1-3 input_file.pdf", appName),
 	fmt.Sprintf("%s render -o images.zip -P 1-3 -p pass input_file.pdf", appName),
 	fmt.Sprintf("%s render -o images.zip -P 1-3 -p pass -f jpeg -q 100 input_file.pdf", appName),
+	fmt.Sprintf("%s render -o images.tar input_file.pdf", appName),
+	fmt.Sprintf("%s render -d images_dir --name-template \"{name}-{page:04}.{ext}\" input_file.pdf", appName),
 )
 
 // renderCmd represents the render command.
@@ -57,6 +70,7 @@ var renderCmd = &cobra.Command{
 		inputPath := args[0]
 		password, _ := cmd.Flags().GetString("password")
 		outputPath, _ := cmd.Flags().GetString("output-file")
+		outputOpts := parseOutputOpts(cmd, outputPath)
 
 		// Parse page range.
 		pageRange, _ := cmd.Flags().GetString("pages")
@@ -80,6 +94,7 @@ var renderCmd = &cobra.Command{
 		opts := &pdf.RenderOpts{
 			ImageFormat:  imageFormat,
 			ImageQuality: imageQuality,
+			OutputOpts:   *outputOpts,
 		}
 
 		// Render file.
@@ -107,6 +122,8 @@ func init() {
 	renderCmd.Flags().StringP("password", "p", "", "input file password")
 	renderCmd.Flags().StringP("output-file", "o", "", "output file")
 	renderCmd.Flags().StringP("pages", "P", "", "pages to render from the input file")
+	renderCmd.Flags().StringP("output-dir", "d", "", "output directory")
+	renderCmd.Flags().String("name-template", "", "template used for naming the output files")
 	renderCmd.Flags().StringP("image-format", "f", "jpeg", "format of the output images")
 	renderCmd.Flags().IntP("image-quality", "q", 100, "quality of the output images")
 }
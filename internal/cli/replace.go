@@ -6,25 +6,75 @@
 package cli
 
 import (
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/unidoc/unipdf-cli/pkg/pdf"
 )
 
-const replaceCmdDesc = `Replace text in PDF files`
+const replaceCmdDesc = `Replace text in PDF files.
 
-var replaceCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+By default, the search is case sensitive and the specified text is searched
+literally. The search can be configured using the following flags:
+  - --regex: the specified text is a regular expression. The replacement
+    text can reference the capture groups of the regular expression
+    (e.g. $1 or ${name}).
+  - --ignore-case: the search is case insensitive
+
+Multiple replacements can be made in a single pass using the --map flag,
+which specifies a CSV file containing a search text and a replacement text
+on each row. If the --map flag is used, the TEXT parameter must be omitted.
+The replacement texts are not searched for other search texts. If the
+occurrences of multiple search texts overlap, the leftmost one is replaced.
+
+The command can be configured to replace text only on the specified pages
+using the --pages parameter.
+
+An example of the pages parameter: 1-3,4,6-7
+Text will only be replaced on pages 1,2,3 (1-3), 4 and 6,7 (6-7), while page
+number 5 is skipped.
+
+By default, text is only replaced in the content of the pages. The --scope
+flag can be used to specify a comma separated list of the parts of the file
+in which text is replaced:
+  - content: the text drawn on the pages
+  - annotations: the text of the annotations (e.g. comments and free text
+    annotations)
+  - forms: the values of the form fields
+  - outline: the titles of the bookmarks
+  - metadata: the document information and the XMP metadata
+The --pages parameter applies to the content and annotations scopes.
+
+The --dry-run flag can be used to print the number of replacements which
+would be made on each page and in each scope, without writing the output
+file.
+
+The replaced text is drawn using the original fonts of the file. If the
+replaced text contains characters which cannot be drawn using the original
+fonts (e.g. accented letters or currency symbols missing from font subsets),
+a warning listing the characters is printed. The --fallback-font flag can be
+used to specify a TrueType font file used for drawing such text instead.
+`
+
+var replaceCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
 	fmt.Sprintf("%s replace input_file.pdf text_to_search", appName),
 	fmt.Sprintf("%s replace -o output_file input_file.pdf text_to_search", appName),
 	fmt.Sprintf("%s replace -o output_file -r new_text input_file.pdf text_to_search", appName),
 	fmt.Sprintf("%s replace -o output_file  -r new_text -p pass input_file.pdf text_to_search", appName),
+	fmt.Sprintf("%s replace -o output_file -E -r \"No. \\$1\" input_file.pdf \"Number ([0-9]+)\"", appName),
+	fmt.Sprintf("%s replace -o output_file -m pairs.csv -i -P 1-3 input_file.pdf", appName),
+	fmt.Sprintf("%s replace --dry-run -m pairs.csv input_file.pdf", appName),
+	fmt.Sprintf("%s replace -o output_file -r \"Zoë\" --fallback-font font.ttf input_file.pdf Zoe", appName),
+	fmt.Sprintf("%s replace -o output_file -m pairs.csv --scope content,annotations,forms,outline,metadata input_file.pdf", appName),
 )
 
 // replaceCmd represents the replace command.
 var replaceCmd = &cobra.Command{
-	Use:                   "replace [FLAG]... INPUT_FILE TEXT",
+	Use:                   "replace [FLAG]... INPUT_FILE [TEXT]",
 	Short:                 "Replace text in PDF files",
 	Long:                  replaceCmdDesc,
 	Example:               replaceCmdExample,
@@ -32,8 +82,22 @@ var replaceCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		// Parse input parameters.
 		inputPath := args[0]
-		text := args[1]
 		password, _ := cmd.Flags().GetString("password")
+		mapPath, _ := cmd.Flags().GetString("map")
+
+		opts := &pdf.ReplaceOpts{}
+		opts.Regex, _ = cmd.Flags().GetBool("regex")
+		opts.IgnoreCase, _ = cmd.Flags().GetBool("ignore-case")
+		opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+		opts.FallbackFontPath, _ = cmd.Flags().GetString("fallback-font")
+
+		// Parse replacement scopes.
+		scopes, _ := cmd.Flags().GetString("scope")
+		for _, scope := range strings.Split(scopes, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				opts.Scopes = append(opts.Scopes, scope)
+			}
+		}
 
 		// Parse output file.
 		outputPath, _ := cmd.Flags().GetString("output-file")
@@ -41,24 +105,76 @@ var replaceCmd = &cobra.Command{
 			outputPath = inputPath
 		}
 
-		// Parse replaceText.
-		replaceText, _ := cmd.Flags().GetString("replace-text")
-		if replaceText == "" {
-			replaceText = text
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+		opts.Pages = pages
+
+		// Parse replacements.
+		var pairs []*pdf.ReplacePair
+		if mapPath != "" {
+			if len(args) > 1 {
+				printUsageErr(cmd, "The TEXT parameter and the --map flag are mutually exclusive\n")
+			}
+
+			if pairs, err = parseReplaceMap(mapPath); err != nil {
+				printErr("Could not read replacement map: %s\n", err)
+			}
+		} else {
+			if len(args) < 2 {
+				printUsageErr(cmd, "Must provide the text to search or the --map flag\n")
+			}
+			text := args[1]
+
+			// Parse replaceText.
+			replaceText, _ := cmd.Flags().GetString("replace-text")
+			if replaceText == "" {
+				replaceText = text
+			}
+
+			pairs = append(pairs, &pdf.ReplacePair{Search: text, Replacement: replaceText})
 		}
 
-		// Search text.
-		err := pdf.Replace(inputPath, outputPath, text, replaceText, password)
+		// Replace text.
+		results, err := pdf.ReplaceAll(inputPath, outputPath, password, pairs, opts)
 		if err != nil {
 			printErr("Could not replace the specified text: %s\n", err)
 		}
 
-		fmt.Printf("Successfully replaced text %s with %s\n", text, replaceText)
-		fmt.Printf("Output file saved to %s\n", outputPath)
+		totalReplacements := 0
+		for _, result := range results {
+			totalReplacements += result.Replacements
+			if opts.DryRun {
+				if result.Page > 0 {
+					fmt.Printf("Page %d (%s): %d replacements\n", result.Page, result.Scope, result.Replacements)
+				} else {
+					fmt.Printf("%s: %d replacements\n", result.Scope, result.Replacements)
+				}
+			}
+
+			if len(result.UnencodableRunes) > 0 {
+				chars := make([]string, 0, len(result.UnencodableRunes))
+				for _, r := range result.UnencodableRunes {
+					chars = append(chars, fmt.Sprintf("%q (%U)", r, r))
+				}
+
+				fmt.Fprintf(os.Stderr, "Warning: page %d: characters not supported by the fonts: %s\n",
+					result.Page, strings.Join(chars, ", "))
+			}
+		}
+
+		fmt.Printf("Total replacements: %d\n", totalReplacements)
+		if !opts.DryRun {
+			fmt.Printf("Output file saved to %s\n", outputPath)
+		}
 	},
 	Args: func(_ *cobra.Command, args []string) error {
-		if len(args) < 2 {
-			return errors.New("must provide a PDF file and the text to search")
+		if len(args) < 1 {
+			return errors.New("must provide a PDF file")
 		}
 
 		return nil
@@ -71,4 +187,44 @@ func init() {
 	replaceCmd.Flags().StringP("output-file", "o", "", "output file")
 	replaceCmd.Flags().StringP("replace-text", "r", "", "replacement text")
 	replaceCmd.Flags().StringP("password", "p", "", "input file password")
+	replaceCmd.Flags().BoolP("regex", "E", false, "interpret the search text as a regular expression")
+	replaceCmd.Flags().BoolP("ignore-case", "i", false, "perform a case insensitive search")
+	replaceCmd.Flags().StringP("map", "m", "", "CSV file containing search and replacement text pairs")
+	replaceCmd.Flags().StringP("pages", "P", "", "pages on which to replace text")
+	replaceCmd.Flags().Bool("dry-run", false, "print the number of replacements without writing the output file")
+	replaceCmd.Flags().String("fallback-font", "", "TrueType font file used for characters not supported by the original fonts")
+	replaceCmd.Flags().String("scope", pdf.ReplaceScopeContent, "comma separated list of the parts of the file in which text is replaced")
+}
+
+// parseReplaceMap reads the search and replacement text pairs from the
+// specified CSV file. Each record must contain a search text and a
+// replacement text.
+func parseReplaceMap(path string) ([]*pdf.ReplacePair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no replacements specified")
+	}
+
+	pairs := make([]*pdf.ReplacePair, 0, len(records))
+	for i, record := range records {
+		if record[0] == "" {
+			return nil, fmt.Errorf("empty search text on line %d", i+1)
+		}
+
+		pairs = append(pairs, &pdf.ReplacePair{Search: record[0], Replacement: record[1]})
+	}
+
+	return pairs, nil
 }
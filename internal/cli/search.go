@@ -6,53 +6,212 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/unidoc/unipdf-cli/pkg/pdf"
 )
 
-const searchCmdDesc = `Search text in PDF files`
+const searchCmdDesc = `Search text in PDF files.
 
-var searchCmdExample = fmt.Sprintf("%s\n%s\n",
+The command accepts one or more PDF files and directories, followed by the
+text to search. The directories are searched for PDF files. If the
+--recursive flag is set, the subdirectories are searched as well. The files
+are searched in parallel. The number of files searched at the same time can
+be specified using the --jobs flag (defaults to the number of CPUs).
+
+By default, the search is case sensitive and the specified text is searched
+literally. The search can be configured using the following flags:
+  - --regex: the specified text is a regular expression
+  - --ignore-case: the search is case insensitive
+  - --whole-word: only whole words are matched
+  - --normalize-whitespace: consecutive whitespace characters (including
+    line breaks) are collapsed into a single space and hyphenated line
+    breaks are removed before searching, which allows matching text which
+    spans multiple lines
+
+The output format can be specified using the --format flag.
+Supported formats:
+  - text (default). If a single input file is specified, the number of
+    occurrences on each page is printed. Otherwise, each occurrence is
+    printed on a separate line, in the file:page: snippet format.
+  - json (the occurrences on each page, along with their offsets in the
+    page text, their bounding boxes in PDF coordinates and a snippet of
+    the surrounding text). Unless a single input file is specified, the
+    results are grouped by file, even if the specified directories contain
+    a single PDF file.
+
+Instead of the occurrences, the text output can contain only the files
+which contain the searched text (--files-with-matches) or the number of
+occurrences in each file (--count).
+
+The number of characters of surrounding text included in the snippets can
+be specified using the --context flag.
+
+The matches can be highlighted using the --highlight flag, which specifies
+the path of a copy of the input file in which a highlight annotation is
+placed over each match. Highlighting is only supported when a single input
+file is specified. The color of the annotations can be specified using
+the --highlight-color flag and a comment can be attached to them using the
+--highlight-comment flag. The annotations can be browsed using the comment
+navigation of any PDF viewer.
+`
+
+var searchCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
 	fmt.Sprintf("%s search input_file.pdf text_to_search", appName),
 	fmt.Sprintf("%s search -p pass input_file.pdf text_to_search", appName),
+	fmt.Sprintf("%s search -i -w input_file.pdf text_to_search", appName),
+	fmt.Sprintf("%s search -E input_file.pdf \"invoice (no|number) [0-9]+\"", appName),
+	fmt.Sprintf("%s search --normalize-whitespace input_file.pdf \"text spanning lines\"", appName),
+	fmt.Sprintf("%s search -f json -C 60 input_file.pdf text_to_search", appName),
+	fmt.Sprintf("%s search --highlight output_file.pdf input_file.pdf text_to_search", appName),
+	fmt.Sprintf("%s search input_file1.pdf input_file2.pdf dir_of_pdf_files text_to_search", appName),
+	fmt.Sprintf("%s search -r -l -i dir_of_pdf_files text_to_search", appName),
+	fmt.Sprintf("%s search -r -c -j 4 dir_of_pdf_files text_to_search", appName),
+	fmt.Sprintf("%s search --highlight output_file.pdf --highlight-color \"#00ff00\" --highlight-comment \"Review\" input_file.pdf text_to_search", appName),
 )
 
 // searchCmd represents the search command.
 var searchCmd = &cobra.Command{
-	Use:                   "search [FLAG]... INPUT_FILE TEXT",
+	Use:                   "search [FLAG]... INPUT_FILES... TEXT",
 	Short:                 "Search text in PDF files",
 	Long:                  searchCmdDesc,
 	Example:               searchCmdExample,
 	DisableFlagsInUseLine: true,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Parse input parameters.
-		inputPath := args[0]
-		text := args[1]
+		text := args[len(args)-1]
 		password, _ := cmd.Flags().GetString("password")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		filesWithMatches, _ := cmd.Flags().GetBool("files-with-matches")
+		count, _ := cmd.Flags().GetBool("count")
+
+		opts := &pdf.SearchOpts{}
+		opts.Regex, _ = cmd.Flags().GetBool("regex")
+		opts.IgnoreCase, _ = cmd.Flags().GetBool("ignore-case")
+		opts.WholeWord, _ = cmd.Flags().GetBool("whole-word")
+		opts.NormalizeWhitespace, _ = cmd.Flags().GetBool("normalize-whitespace")
+		opts.Context, _ = cmd.Flags().GetInt("context")
+
+		highlightPath, _ := cmd.Flags().GetString("highlight")
+		highlightOpts := &pdf.HighlightOpts{}
+		highlightOpts.Color, _ = cmd.Flags().GetString("highlight-color")
+		highlightOpts.Comment, _ = cmd.Flags().GetString("highlight-comment")
+
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			printUsageErr(cmd, "Unsupported output format: %s\n", format)
+		}
+		if opts.Context < 0 {
+			printUsageErr(cmd, "The context must not be negative\n")
+		}
+		if filesWithMatches && count {
+			printUsageErr(cmd, "The --files-with-matches and the --count flags are mutually exclusive\n")
+		}
+		if (filesWithMatches || count) && format != "text" {
+			printUsageErr(cmd, "The --files-with-matches and the --count flags require the text format\n")
+		}
+
+		// The files specified explicitly are searched regardless of their
+		// extension. Only the files found in directories must be PDF files.
+		inputArgs := args[:len(args)-1]
+		explicit := map[string]bool{}
+		for _, inputArg := range inputArgs {
+			if inputPath, err := filepath.Abs(inputArg); err == nil {
+				explicit[inputPath] = true
+			}
+		}
+		matcher := func(inputPath string) bool {
+			return explicit[inputPath] || pdfMatcher(inputPath)
+		}
+
+		inputPaths, err := parseInputPaths(inputArgs, recursive, matcher)
+		if err != nil {
+			printErr("Could not parse input files: %s\n", err)
+		}
+		if len(inputPaths) == 0 {
+			printErr("No PDF files found\n")
+		}
+
+		// The output of single files does not depend on the contents of
+		// directories.
+		var singleFile bool
+		if len(inputArgs) == 1 {
+			info, err := os.Stat(inputArgs[0])
+			singleFile = err == nil && info.Mode().IsRegular()
+		}
+		if highlightPath != "" && !singleFile {
+			printUsageErr(cmd, "The --highlight flag requires a single input file\n")
+		}
+
+		// Search a single file.
+		if singleFile && !filesWithMatches && !count {
+			searchFile(inputPaths[0], text, password, format, opts, highlightPath, highlightOpts)
+			return
+		}
 
-		// Search text.
-		results, err := pdf.Search(inputPath, text, password)
+		// Search multiple files.
+		fileResults, err := pdf.SearchFiles(inputPaths, text, password, opts, jobs)
 		if err != nil {
 			printErr("Could not search the specified text: %s\n", err)
 		}
 
+		var failed bool
+		for _, fileResult := range fileResults {
+			if fileResult.Error != "" {
+				failed = true
+				fmt.Fprintf(os.Stderr, "Could not search %s: %s\n", fileResult.File, fileResult.Error)
+			}
+		}
+
 		// Print results.
-		fmt.Printf("Search results for term: %s\n", text)
+		switch {
+		case format == "json":
+			data, err := json.MarshalIndent(fileResults, "", "  ")
+			if err != nil {
+				printErr("Could not encode search results: %s\n", err)
+			}
+
+			fmt.Println(string(data))
+		case filesWithMatches:
+			for _, fileResult := range fileResults {
+				if fileResult.Occurrences > 0 {
+					fmt.Println(fileResult.File)
+				}
+			}
+		case count:
+			for _, fileResult := range fileResults {
+				if fileResult.Error == "" {
+					fmt.Printf("%s:%d\n", fileResult.File, fileResult.Occurrences)
+				}
+			}
+		default:
+			for _, fileResult := range fileResults {
+				for _, result := range fileResult.Results {
+					for _, match := range result.Matches {
+						line := match.Snippet
+						if line == "" {
+							line = match.Text
+						}
 
-		totalOccurrences := 0
-		for _, result := range results {
-			totalOccurrences += result.Occurrences
-			fmt.Printf("Page %d: %d occurrences\n", result.Page, result.Occurrences)
+						fmt.Printf("%s:%d: %s\n", fileResult.File, result.Page, line)
+					}
+				}
+			}
 		}
 
-		fmt.Printf("Total occurrences: %d\n", totalOccurrences)
+		if failed {
+			os.Exit(1)
+		}
 	},
 	Args: func(_ *cobra.Command, args []string) error {
 		if len(args) < 2 {
-			return errors.New("must provide a PDF file and the text to search")
+			return errors.New("must provide at least one PDF file and the text to search")
 		}
 
 		return nil
@@ -63,4 +222,66 @@ func init() {
 	rootCmd.AddCommand(searchCmd)
 
 	searchCmd.Flags().StringP("password", "p", "", "input file password")
+	searchCmd.Flags().BoolP("recursive", "r", false, "search PDF files in subdirectories")
+	searchCmd.Flags().IntP("jobs", "j", 0, "number of files searched in parallel (default number of CPUs)")
+	searchCmd.Flags().BoolP("files-with-matches", "l", false, "print only the files containing the search text")
+	searchCmd.Flags().BoolP("count", "c", false, "print only the number of occurrences in each file")
+	searchCmd.Flags().BoolP("regex", "E", false, "interpret the search text as a regular expression")
+	searchCmd.Flags().BoolP("ignore-case", "i", false, "perform a case insensitive search")
+	searchCmd.Flags().BoolP("whole-word", "w", false, "match whole words only")
+	searchCmd.Flags().Bool("normalize-whitespace", false, "collapse whitespace, line breaks and hyphenation")
+	searchCmd.Flags().StringP("format", "f", "text", "output format (text, json)")
+	searchCmd.Flags().IntP("context", "C", 40, "number of context characters included in snippets")
+	searchCmd.Flags().String("highlight", "", "output file with the matches highlighted")
+	searchCmd.Flags().String("highlight-color", "#ffff00", "highlight color")
+	searchCmd.Flags().String("highlight-comment", "", "highlight comment")
+}
+
+// searchFile searches the provided text in the specified file and prints
+// the results using the specified format. If a highlight path is provided,
+// a copy of the file is saved to it, with the occurrences highlighted.
+func searchFile(inputPath, text, password, format string, opts *pdf.SearchOpts,
+	highlightPath string, highlightOpts *pdf.HighlightOpts) {
+	// Search text.
+	results, err := pdf.SearchWithOpts(inputPath, text, password, opts)
+	if err != nil {
+		printErr("Could not search the specified text: %s\n", err)
+	}
+
+	// Highlight matches.
+	if highlightPath != "" {
+		err := pdf.Highlight(inputPath, highlightPath, password, results, highlightOpts)
+		if err != nil {
+			printErr("Could not highlight search results: %s\n", err)
+		}
+	}
+
+	// Print results.
+	if format == "json" {
+		if results == nil {
+			results = []*pdf.SearchResult{}
+		}
+
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			printErr("Could not encode search results: %s\n", err)
+		}
+
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("Search results for term: %s\n", text)
+
+	totalOccurrences := 0
+	for _, result := range results {
+		totalOccurrences += result.Occurrences
+		fmt.Printf("Page %d: %d occurrences\n", result.Page, result.Occurrences)
+	}
+
+	fmt.Printf("Total occurrences: %d\n", totalOccurrences)
+
+	if highlightPath != "" {
+		fmt.Printf("Search results successfully highlighted in %s\n", highlightPath)
+	}
 }
@@ -0,0 +1,132 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const stampCmdDesc = `Stamp headers and footers on the pages of PDF files.
+
+The texts of the headers and footers are specified using the --header-left,
+--header-center, --header-right, --footer-left, --footer-center and
+--footer-right flags. The texts are templates which can contain the following
+placeholders:
+  - {page}: the current page number
+  - {total}: the total number of pages
+  - {date}: the current date. A Go time layout can be specified after a
+    colon (e.g. {date:2006-01-02})
+  - {file}: the name of the input file
+  - {title}: the title of the input file
+
+The command can be configured to stamp only the specified pages using
+the --pages parameter. The first page of the file can be skipped using
+the --skip-first flag.
+
+An example of the pages parameter: 1-3,4,6-7
+Only pages 1,2,3 (1-3), 4 and 6,7 (6-7) will be stamped, while page
+number 5 is skipped.
+`
+
+var stampCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s stamp --footer-center \"Page {page} of {total}\" input_file.pdf", appName),
+	fmt.Sprintf("%s stamp -o output_file.pdf --header-right \"{title} - {date:2006-01-02}\" input_file.pdf", appName),
+	fmt.Sprintf("%s stamp -o output_file.pdf -P 2-10 --footer-right \"{page}\" -s 8 -c \"#808080\" input_file.pdf", appName),
+	fmt.Sprintf("%s stamp -o output_file.pdf --skip-first --header-left \"{file}\" -p pass input_file.pdf", appName),
+)
+
+// stampCmd represents the stamp command.
+var stampCmd = &cobra.Command{
+	Use:                   "stamp [FLAG]... INPUT_FILE",
+	Short:                 "Stamp headers and footers on PDF files",
+	Long:                  stampCmdDesc,
+	Example:               stampCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+
+		// Parse output file.
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		if outputPath == "" {
+			outputPath = inputPath
+		}
+
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+
+		// Parse stamp options.
+		opts := parseStampOpts(cmd)
+		if opts.HeaderLeft == "" && opts.HeaderCenter == "" && opts.HeaderRight == "" &&
+			opts.FooterLeft == "" && opts.FooterCenter == "" && opts.FooterRight == "" {
+			printUsageErr(cmd, "Must specify at least one header or footer text\n")
+		}
+
+		// Stamp file.
+		if err := pdf.Stamp(inputPath, outputPath, password, pages, opts); err != nil {
+			printErr("Could not stamp the input file: %s\n", err)
+		}
+
+		fmt.Printf("Successfully stamped %s\n", inputPath)
+		fmt.Printf("Output file saved to %s\n", outputPath)
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("must provide the input file")
+		}
+
+		return nil
+	},
+}
+
+func parseStampOpts(cmd *cobra.Command) *pdf.StampOpts {
+	opts := &pdf.StampOpts{}
+	opts.HeaderLeft, _ = cmd.Flags().GetString("header-left")
+	opts.HeaderCenter, _ = cmd.Flags().GetString("header-center")
+	opts.HeaderRight, _ = cmd.Flags().GetString("header-right")
+	opts.FooterLeft, _ = cmd.Flags().GetString("footer-left")
+	opts.FooterCenter, _ = cmd.Flags().GetString("footer-center")
+	opts.FooterRight, _ = cmd.Flags().GetString("footer-right")
+	opts.FontName, _ = cmd.Flags().GetString("font")
+	opts.FontPath, _ = cmd.Flags().GetString("font-file")
+	opts.FontSize, _ = cmd.Flags().GetFloat64("font-size")
+	opts.Color, _ = cmd.Flags().GetString("color")
+	margin, _ := cmd.Flags().GetFloat64("margin")
+	opts.Margin = &margin
+	opts.SkipFirst, _ = cmd.Flags().GetBool("skip-first")
+
+	return opts
+}
+
+func init() {
+	rootCmd.AddCommand(stampCmd)
+
+	stampCmd.Flags().StringP("output-file", "o", "", "output file")
+	stampCmd.Flags().StringP("password", "p", "", "input file password")
+	stampCmd.Flags().StringP("pages", "P", "", "pages to stamp")
+	stampCmd.Flags().String("header-left", "", "text of the left header")
+	stampCmd.Flags().String("header-center", "", "text of the center header")
+	stampCmd.Flags().String("header-right", "", "text of the right header")
+	stampCmd.Flags().String("footer-left", "", "text of the left footer")
+	stampCmd.Flags().String("footer-center", "", "text of the center footer")
+	stampCmd.Flags().String("footer-right", "", "text of the right footer")
+	stampCmd.Flags().String("font", "Helvetica", "standard font used for the texts")
+	stampCmd.Flags().String("font-file", "", "TrueType font file used for the texts")
+	stampCmd.Flags().Float64P("font-size", "s", 10, "font size of the texts")
+	stampCmd.Flags().StringP("color", "c", "#000000", "color of the texts")
+	stampCmd.Flags().Float64P("margin", "m", 36, "distance between the texts and the page edges")
+	stampCmd.Flags().Bool("skip-first", false, "do not stamp the first page")
+}
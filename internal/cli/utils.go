@@ -6,6 +6,7 @@
 package cli
 
 import (
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"os"
@@ -16,6 +17,7 @@ import (
 	"unicode"
 
 	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
 )
 
 type fileMatcher func(string) bool
@@ -190,6 +192,10 @@ func parseInputDir(dir string, recursive bool, acc map[string]bool, matcher file
 		return nil, err
 	}
 
+	// Sort the directory entries by name, so that the files are always
+	// processed in the same order, regardless of the file system.
+	sort.Strings(inputPaths)
+
 	if acc == nil {
 		acc = map[string]bool{}
 	}
@@ -263,6 +269,24 @@ func generateOutputPath(inputPath, outputDir, nameSuffix string, overwrite bool)
 	return filepath.Join(dir, fmt.Sprintf("%s_%s.pdf", name, nameSuffix))
 }
 
+func parseOutputOpts(cmd *cobra.Command, outputPath string) *pdf.OutputOpts {
+	nameTemplate, _ := cmd.Flags().GetString("name-template")
+
+	return &pdf.OutputOpts{
+		Dir:          parseOutputDir(cmd, outputPath),
+		NameTemplate: nameTemplate,
+	}
+}
+
+func parseOutputDir(cmd *cobra.Command, outputPath string) string {
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	if outputDir != "" && outputPath != "" {
+		printUsageErr(cmd, "The --output-file and --output-dir flags cannot be used together\n")
+	}
+
+	return outputDir
+}
+
 func clampInt(val, minimum, maximum int) int {
 	if val < minimum {
 		return minimum
@@ -298,6 +322,21 @@ func uniqueIntSlice(items []int) []int {
 	return uniq[0:index]
 }
 
+func writeCSVFile(path string, records [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
 func printErr(format string, a ...interface{}) {
 	fmt.Printf(format, a...)
 	os.Exit(1)
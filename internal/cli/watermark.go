@@ -15,24 +15,59 @@ import (
 
 const watermarkCmdDesc = `Add watermark to PDF files.
 
-The command can be configured to apply the watermark image only to the specified
+The watermark can be an image, specified as the second argument of the
+command, or a text, specified using the --text flag.
+
+The command can be configured to apply the watermark only to the specified
 pages using the --pages parameter.
 
 An example of the pages parameter: 1-3,4,6-7
 Watermark will only be applied to pages 1,2,3 (1-3), 4 and 6,7 (6-7), while page
 number 5 is skipped.
+
+The position of the watermark can be specified using the --position flag.
+Supported positions:
+  - center (default)
+  - top-left, top, top-right
+  - left, right
+  - bottom-left, bottom, bottom-right
+  - custom (the center of the watermark is specified using the --pos-x and
+    --pos-y flags, relative to the bottom-left corner of the page)
+
+The watermark can be rotated using the --angle flag and scaled relative to
+the page width using the --scale flag. Use the --tile flag to repeat the
+watermark across the whole page and the --under flag to draw the watermark
+under the page contents.
+
+Text watermarks use the standard font specified by the --font flag
+(default Helvetica). Alternatively, a TrueType font file can be specified
+using the --font-file flag.
+
+The watermark can be added to a named optional content group (layer) using
+the --layer flag, which allows PDF viewers to hide it. The --visibility flag
+can be used in order to make the watermark visible only when printing or
+only on screen. Supported visibility values:
+  - all (default)
+  - print
+  - screen
+
+Watermarks added by the application can be removed using the
+"watermark remove" command.
 `
 
-var watermarkCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+var watermarkCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
 	fmt.Sprintf("%s watermark input_file.pdf watermark.png", appName),
-	fmt.Sprintf("%s watermark -o output file.png input_file.pdf watermark.png", appName),
-	fmt.Sprintf("%s watermark -o output file.png -P 1-3 input_file.pdf watermark.png", appName),
-	fmt.Sprintf("%s watermark -o output file.png -P 1-3 -p pass input_file.pdf watermark.png", appName),
+	fmt.Sprintf("%s watermark -o output_file.pdf input_file.pdf watermark.png", appName),
+	fmt.Sprintf("%s watermark -o output_file.pdf -P 1-3 input_file.pdf watermark.png", appName),
+	fmt.Sprintf("%s watermark -o output_file.pdf -P 1-3 -p pass input_file.pdf watermark.png", appName),
+	fmt.Sprintf("%s watermark -o output_file.pdf -t CONFIDENTIAL -a 45 -c \"#ff0000\" input_file.pdf", appName),
+	fmt.Sprintf("%s watermark -o output_file.pdf -t DRAFT -s 24 --tile --under input_file.pdf", appName),
+	fmt.Sprintf("%s watermark -o output_file.pdf -t COPY --layer Watermark --visibility print input_file.pdf", appName),
 )
 
 // watermarkCmd represents the watermark command.
 var watermarkCmd = &cobra.Command{
-	Use:                   "watermark [FLAG]... INPUT_FILE WATERMARK_IMAGE",
+	Use:                   "watermark [FLAG]... INPUT_FILE [WATERMARK_IMAGE]",
 	Short:                 "Add watermark to PDF files",
 	Long:                  watermarkCmdDesc,
 	Example:               watermarkCmdExample,
@@ -40,7 +75,6 @@ var watermarkCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		// Parse input parameters.
 		inputPath := args[0]
-		watermarkPath := args[1]
 		password, _ := cmd.Flags().GetString("password")
 
 		// Parse output file.
@@ -57,8 +91,11 @@ var watermarkCmd = &cobra.Command{
 			printUsageErr(cmd, "Invalid page range specified\n")
 		}
 
+		// Parse watermark options.
+		opts := parseWatermarkOpts(cmd, args)
+
 		// Apply watermark.
-		err = pdf.Watermark(inputPath, outputPath, watermarkPath, password, pages)
+		err = pdf.WatermarkWithOpts(inputPath, outputPath, password, pages, opts)
 		if err != nil {
 			printErr("Could not apply watermark to the input file: %s\n", err)
 		}
@@ -66,19 +103,65 @@ var watermarkCmd = &cobra.Command{
 		fmt.Printf("Watermark successfully applied to %s\n", inputPath)
 		fmt.Printf("Output file saved to %s\n", outputPath)
 	},
-	Args: func(_ *cobra.Command, args []string) error {
-		if len(args) < 2 {
-			return errors.New("must provide the input file and the watermark image")
+	Args: func(cmd *cobra.Command, args []string) error {
+		text, _ := cmd.Flags().GetString("text")
+		if len(args) < 1 || (len(args) < 2 && text == "") {
+			return errors.New("must provide the input file and the watermark image or text")
 		}
 
 		return nil
 	},
 }
 
+func parseWatermarkOpts(cmd *cobra.Command, args []string) *pdf.WatermarkOpts {
+	opts := &pdf.WatermarkOpts{}
+	opts.Text, _ = cmd.Flags().GetString("text")
+	if opts.Text == "" && len(args) > 1 {
+		opts.ImagePath = args[1]
+	}
+
+	opts.FontName, _ = cmd.Flags().GetString("font")
+	opts.FontPath, _ = cmd.Flags().GetString("font-file")
+	opts.FontSize, _ = cmd.Flags().GetFloat64("font-size")
+	opts.Color, _ = cmd.Flags().GetString("color")
+	opts.Position, _ = cmd.Flags().GetString("position")
+	opts.X, _ = cmd.Flags().GetFloat64("pos-x")
+	opts.Y, _ = cmd.Flags().GetFloat64("pos-y")
+	opts.Margin, _ = cmd.Flags().GetFloat64("margin")
+	opts.Angle, _ = cmd.Flags().GetFloat64("angle")
+	opts.Scale, _ = cmd.Flags().GetFloat64("scale")
+	opacity, _ := cmd.Flags().GetFloat64("opacity")
+	opts.Opacity = &opacity
+	opts.Tile, _ = cmd.Flags().GetBool("tile")
+	opts.TileSpacing, _ = cmd.Flags().GetFloat64("tile-spacing")
+	opts.Under, _ = cmd.Flags().GetBool("under")
+	opts.Layer, _ = cmd.Flags().GetString("layer")
+	opts.Visibility, _ = cmd.Flags().GetString("visibility")
+
+	return opts
+}
+
 func init() {
 	rootCmd.AddCommand(watermarkCmd)
 
 	watermarkCmd.Flags().StringP("output-file", "o", "", "output file")
 	watermarkCmd.Flags().StringP("password", "p", "", "input file password")
 	watermarkCmd.Flags().StringP("pages", "P", "", "pages on which to add watermark")
+	watermarkCmd.Flags().StringP("text", "t", "", "watermark text")
+	watermarkCmd.Flags().String("font", "Helvetica", "standard font used for text watermarks")
+	watermarkCmd.Flags().String("font-file", "", "TrueType font file used for text watermarks")
+	watermarkCmd.Flags().Float64P("font-size", "s", 48, "font size of text watermarks")
+	watermarkCmd.Flags().StringP("color", "c", "#808080", "color of text watermarks")
+	watermarkCmd.Flags().String("position", "center", "position of the watermark")
+	watermarkCmd.Flags().Float64("pos-x", 0, "horizontal position of the watermark center")
+	watermarkCmd.Flags().Float64("pos-y", 0, "vertical position of the watermark center")
+	watermarkCmd.Flags().Float64("margin", 20, "distance between the watermark and the page edges")
+	watermarkCmd.Flags().Float64P("angle", "a", 0, "watermark rotation angle in degrees")
+	watermarkCmd.Flags().Float64("scale", 0, "watermark width relative to the page width (0-1)")
+	watermarkCmd.Flags().Float64("opacity", 0.5, "watermark opacity (0-1)")
+	watermarkCmd.Flags().Bool("tile", false, "repeat the watermark across the page")
+	watermarkCmd.Flags().Float64("tile-spacing", 50, "distance between tiled watermarks")
+	watermarkCmd.Flags().Bool("under", false, "draw the watermark under the page contents")
+	watermarkCmd.Flags().String("layer", "", "name of the watermark layer")
+	watermarkCmd.Flags().String("visibility", "all", "visibility of the watermark layer")
 }
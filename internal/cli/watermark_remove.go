@@ -0,0 +1,90 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/unidoc/unipdf-cli/pkg/pdf"
+)
+
+const watermarkRemoveCmdDesc = `Remove watermarks from PDF files.
+
+The command removes the watermarks previously added by the application,
+identified by their marked-content tag or by their watermark layer.
+In addition, all the content of a specific layer can be removed by using
+the --layer flag.
+
+The command can be configured to remove watermarks only from the specified
+pages using the --pages parameter.
+
+An example of the pages parameter: 1-3,4,6-7
+Watermarks will only be removed from pages 1,2,3 (1-3), 4 and 6,7 (6-7), while
+page number 5 is skipped.
+`
+
+var watermarkRemoveCmdExample = fmt.Sprintf("%s\n%s\n%s\n%s\n",
+	fmt.Sprintf("%s watermark remove input_file.pdf", appName),
+	fmt.Sprintf("%s watermark remove -o output_file.pdf input_file.pdf", appName),
+	fmt.Sprintf("%s watermark remove -o output_file.pdf -P 1-3 -p pass input_file.pdf", appName),
+	fmt.Sprintf("%s watermark remove -o output_file.pdf --layer Watermark input_file.pdf", appName),
+)
+
+// watermarkRemoveCmd represents the watermark remove command.
+var watermarkRemoveCmd = &cobra.Command{
+	Use:                   "remove [FLAG]... INPUT_FILE",
+	Short:                 "Remove watermarks from PDF files",
+	Long:                  watermarkRemoveCmdDesc,
+	Example:               watermarkRemoveCmdExample,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Parse input parameters.
+		inputPath := args[0]
+		password, _ := cmd.Flags().GetString("password")
+		layer, _ := cmd.Flags().GetString("layer")
+
+		// Parse output file.
+		outputPath, _ := cmd.Flags().GetString("output-file")
+		if outputPath == "" {
+			outputPath = inputPath
+		}
+
+		// Parse page range.
+		pageRange, _ := cmd.Flags().GetString("pages")
+
+		pages, err := parsePageRange(pageRange)
+		if err != nil {
+			printUsageErr(cmd, "Invalid page range specified\n")
+		}
+
+		// Remove watermarks.
+		count, err := pdf.RemoveWatermarks(inputPath, outputPath, password, pages, layer)
+		if err != nil {
+			printErr("Could not remove watermarks from the input file: %s\n", err)
+		}
+
+		fmt.Printf("Removed %d watermarks from %s\n", count, inputPath)
+		fmt.Printf("Output file saved to %s\n", outputPath)
+	},
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("must provide the input file")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	watermarkCmd.AddCommand(watermarkRemoveCmd)
+
+	watermarkRemoveCmd.Flags().StringP("output-file", "o", "", "output file")
+	watermarkRemoveCmd.Flags().StringP("password", "p", "", "input file password")
+	watermarkRemoveCmd.Flags().StringP("pages", "P", "", "pages to remove watermarks from")
+	watermarkRemoveCmd.Flags().String("layer", "", "name of the layer to remove")
+}
@@ -0,0 +1,192 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+)
+
+type altoDoc struct {
+	XMLName        xml.Name        `xml:"alto"`
+	Xmlns          string          `xml:"xmlns,attr"`
+	XmlnsXSI       string          `xml:"xmlns:xsi,attr"`
+	SchemaLocation string          `xml:"xsi:schemaLocation,attr"`
+	Description    altoDescription `xml:"Description"`
+	Pages          []altoPage      `xml:"Layout>Page"`
+}
+
+type altoDescription struct {
+	MeasurementUnit string `xml:"MeasurementUnit"`
+	FileName        string `xml:"sourceImageInformation>fileName"`
+	SoftwareName    string `xml:"OCRProcessing>ocrProcessingStep>processingSoftware>softwareName"`
+}
+
+type altoPage struct {
+	ID          string         `xml:"ID,attr"`
+	PhysicalNum int            `xml:"PHYSICAL_IMG_NR,attr"`
+	Width       float64        `xml:"WIDTH,attr"`
+	Height      float64        `xml:"HEIGHT,attr"`
+	PrintSpace  altoPrintSpace `xml:"PrintSpace"`
+}
+
+type altoPrintSpace struct {
+	altoBox
+	Blocks []altoBlock `xml:"TextBlock"`
+}
+
+type altoBlock struct {
+	ID string `xml:"ID,attr"`
+	altoBox
+	Lines []altoLine `xml:"TextLine"`
+}
+
+type altoLine struct {
+	ID string `xml:"ID,attr"`
+	altoBox
+	Elems []altoElem
+}
+
+type altoElem struct {
+	XMLName    xml.Name
+	ID         string  `xml:"ID,attr,omitempty"`
+	HPos       float64 `xml:"HPOS,attr"`
+	VPos       float64 `xml:"VPOS,attr"`
+	Width      float64 `xml:"WIDTH,attr"`
+	Height     float64 `xml:"HEIGHT,attr,omitempty"`
+	Content    string  `xml:"CONTENT,attr,omitempty"`
+	Confidence float64 `xml:"WC,attr,omitempty"`
+}
+
+type altoBox struct {
+	HPos   float64 `xml:"HPOS,attr"`
+	VPos   float64 `xml:"VPOS,attr"`
+	Width  float64 `xml:"WIDTH,attr"`
+	Height float64 `xml:"HEIGHT,attr"`
+}
+
+// newAltoBox returns the ALTO representation of the specified rectangle,
+// relative to the top-left corner of the provided page box.
+func newAltoBox(r, box Rect) altoBox {
+	round := func(val float64) float64 {
+		return math.Round(val*100) / 100
+	}
+
+	return altoBox{
+		HPos:   round(r.Llx - box.Llx),
+		VPos:   round(box.Ury - r.Ury),
+		Width:  round(r.Width()),
+		Height: round(r.Height()),
+	}
+}
+
+// EncodeALTO encodes the provided page texts as an ALTO (version 4) XML
+// document. Each page contains a single text block, which contains the
+// text lines and the words of the page, along with their bounding boxes.
+// The coordinates are expressed in points (the measurement unit is set to
+// pixel, at a resolution of 72 DPI), relative to the top-left corner of the
+// visible area of the page. As the text is not the result of OCR, the
+// confidence of each word is set to 1.
+func EncodeALTO(pageTexts []*PageText, fileName string) ([]byte, error) {
+	doc := altoDoc{
+		Xmlns:          "http://www.loc.gov/standards/alto/ns-v4#",
+		XmlnsXSI:       "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: "http://www.loc.gov/standards/alto/ns-v4# http://www.loc.gov/alto/v4/alto-4-2.xsd",
+		Description: altoDescription{
+			MeasurementUnit: "pixel",
+			FileName:        fileName,
+			SoftwareName:    "unipdf",
+		},
+	}
+
+	for i, pageText := range pageTexts {
+		box := pageText.BBox
+		pageBox := newAltoBox(box, box)
+
+		block := altoBlock{ID: fmt.Sprintf("block_%d", pageText.Page)}
+		for j, line := range pageText.Lines {
+			words := line.words()
+			if len(words) == 0 {
+				continue
+			}
+
+			lineElem := altoLine{
+				ID:      fmt.Sprintf("line_%d_%d", pageText.Page, j+1),
+				altoBox: newAltoBox(line.BBox, box),
+			}
+
+			for k, word := range words {
+				if k > 0 {
+					// Add the space between the words.
+					prev := words[k-1].bbox
+					sp := newAltoBox(Rect{
+						Llx: prev.Urx,
+						Lly: line.BBox.Lly,
+						Urx: math.Max(prev.Urx, word.bbox.Llx),
+						Ury: line.BBox.Ury,
+					}, box)
+
+					lineElem.Elems = append(lineElem.Elems, altoElem{
+						XMLName: xml.Name{Local: "SP"},
+						HPos:    sp.HPos,
+						VPos:    sp.VPos,
+						Width:   sp.Width,
+					})
+				}
+
+				wb := newAltoBox(word.bbox, box)
+				lineElem.Elems = append(lineElem.Elems, altoElem{
+					XMLName:    xml.Name{Local: "String"},
+					ID:         fmt.Sprintf("string_%d_%d_%d", pageText.Page, j+1, k+1),
+					HPos:       wb.HPos,
+					VPos:       wb.VPos,
+					Width:      wb.Width,
+					Height:     wb.Height,
+					Content:    word.text,
+					Confidence: 1,
+				})
+			}
+
+			block.Lines = append(block.Lines, lineElem)
+		}
+
+		page := altoPage{
+			ID:          fmt.Sprintf("page_%d", pageText.Page),
+			PhysicalNum: i + 1,
+			Width:       pageBox.Width,
+			Height:      pageBox.Height,
+			PrintSpace:  altoPrintSpace{altoBox: pageBox},
+		}
+		if len(block.Lines) > 0 {
+			block.altoBox = newAltoBox(blockBBox(pageText.Lines), box)
+			page.PrintSpace.Blocks = []altoBlock{block}
+		}
+
+		doc.Pages = append(doc.Pages, page)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", " ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte(xml.Header), data...), '\n'), nil
+}
+
+// blockBBox returns the bounding box of the provided text lines.
+func blockBBox(lines []TextLine) Rect {
+	var bbox Rect
+	for i, line := range lines {
+		if i == 0 {
+			bbox = line.BBox
+			continue
+		}
+		bbox = bbox.union(line.BBox)
+	}
+
+	return bbox
+}
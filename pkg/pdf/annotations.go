@@ -0,0 +1,429 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	unicore "github.com/unidoc/unipdf/v4/core"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// Annotation contains the information of an annotation (e.g. a comment or
+// a highlight) extracted from a PDF file.
+type Annotation struct {
+	// ID is the name of the annotation (NM entry), if specified. Otherwise,
+	// the ID is generated from the page number and the index of the
+	// annotation on the page (e.g. p3_a1).
+	ID string `json:"id"`
+
+	// Type is the subtype of the annotation (e.g. Text, Highlight).
+	Type string `json:"type"`
+
+	// Page is the number of the page the annotation is placed on.
+	Page int `json:"page"`
+
+	// Rect is the area of the page covered by the annotation.
+	Rect Rect `json:"rect"`
+
+	// Author is the author of the annotation.
+	Author string `json:"author,omitempty"`
+
+	// Subject is the subject of the annotation.
+	Subject string `json:"subject,omitempty"`
+
+	// Contents is the text of the annotation.
+	Contents string `json:"contents,omitempty"`
+
+	// Created and Modified contain the creation and the modification dates
+	// of the annotation, in RFC 3339 format.
+	Created  string `json:"created,omitempty"`
+	Modified string `json:"modified,omitempty"`
+
+	// Text is the page text covered by text markup annotations
+	// (e.g. the highlighted text).
+	Text string `json:"text,omitempty"`
+
+	// InReplyTo is the ID of the annotation this annotation replies to.
+	InReplyTo string `json:"in_reply_to,omitempty"`
+
+	// Replies contains the replies to the annotation. The field is only
+	// populated when encoding the annotations as threads.
+	Replies []*Annotation `json:"replies,omitempty"`
+}
+
+// ExtractAnnotations returns the annotations of the PDF file specified by
+// the inputPath parameter, in page order. Popup and widget (form field)
+// annotations are skipped. For text markup annotations (e.g. highlights),
+// the text covered by the annotation is extracted from the page.
+// A password can be passed in, if the input file is encrypted.
+// Also, a list of pages from which to extract annotations can be passed in.
+// If the pages parameter is nil or an empty slice, the annotations are
+// extracted from all the pages of the file.
+func ExtractAnnotations(inputPath, password string, pages []int) ([]*Annotation, error) {
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract annotations.
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
+
+	var annotations []*Annotation
+	annotDicts := map[*unicore.PdfObjectDictionary]*Annotation{}
+	replies := map[*Annotation]*unicore.PdfObjectDictionary{}
+
+	for _, numPage := range pages {
+		// Get page.
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return nil, err
+		}
+
+		annots, err := page.GetAnnotations()
+		if err != nil {
+			return nil, err
+		}
+
+		var pageText *PageText
+		for i, annot := range annots {
+			dict, ok := unicore.GetDict(annot.GetContainingPdfObject())
+			if !ok {
+				continue
+			}
+
+			subtype, _ := unicore.GetNameVal(dict.Get("Subtype"))
+			if subtype == "Popup" || subtype == "Widget" {
+				continue
+			}
+
+			a := &Annotation{
+				ID:       fmt.Sprintf("p%d_a%d", numPage, i+1),
+				Type:     subtype,
+				Page:     numPage,
+				Author:   decodedString(dict.Get("T")),
+				Subject:  decodedString(dict.Get("Subj")),
+				Contents: decodedString(dict.Get("Contents")),
+				Created:  annotationDate(dict.Get("CreationDate")),
+				Modified: annotationDate(dict.Get("M")),
+			}
+			if name := decodedString(dict.Get("NM")); name != "" {
+				a.ID = name
+			}
+			a.Rect = annotationRect(dict)
+
+			// Extract the text covered by text markup annotations.
+			switch subtype {
+			case "Highlight", "Underline", "StrikeOut", "Squiggly":
+				if pageText == nil {
+					if pageText, err = newPageText(page, numPage); err != nil {
+						return nil, err
+					}
+				}
+
+				a.Text = pageText.areaText(markupAreas(dict, a.Rect))
+			}
+
+			if parent, ok := unicore.GetDict(dict.Get("IRT")); ok {
+				replies[a] = parent
+			}
+
+			annotDicts[dict] = a
+			annotations = append(annotations, a)
+		}
+	}
+
+	// Resolve reply chains.
+	for a, parentDict := range replies {
+		if parent, ok := annotDicts[parentDict]; ok {
+			a.InReplyTo = parent.ID
+		} else {
+			a.InReplyTo = decodedString(parentDict.Get("NM"))
+		}
+	}
+
+	return annotations, nil
+}
+
+// annotationRect returns the rectangle of the specified annotation.
+func annotationRect(dict *unicore.PdfObjectDictionary) Rect {
+	arr, ok := unicore.GetArray(dict.Get("Rect"))
+	if !ok {
+		return Rect{}
+	}
+
+	vals, err := arr.ToFloat64Array()
+	if err != nil || len(vals) != 4 {
+		return Rect{}
+	}
+
+	return Rect{
+		Llx: math.Min(vals[0], vals[2]),
+		Lly: math.Min(vals[1], vals[3]),
+		Urx: math.Max(vals[0], vals[2]),
+		Ury: math.Max(vals[1], vals[3]),
+	}
+}
+
+// annotationMarkup returns the markup fields of the specified annotation,
+// or nil if the annotation is not a markup annotation.
+func annotationMarkup(annot *unipdf.PdfAnnotation) *unipdf.PdfAnnotationMarkup {
+	switch a := annot.GetContext().(type) {
+	case *unipdf.PdfAnnotationText:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationFreeText:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationLine:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationSquare:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationCircle:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationPolygon:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationPolyLine:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationHighlight:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationUnderline:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationSquiggly:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationStrikeOut:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationCaret:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationStamp:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationInk:
+		return &a.PdfAnnotationMarkup
+	case *unipdf.PdfAnnotationFileAttachment:
+		return &a.PdfAnnotationMarkup
+	}
+
+	return nil
+}
+
+// markupAreas returns the areas covered by the specified text markup
+// annotation. The areas are specified by the QuadPoints entry of the
+// annotation. If the entry is missing, the rectangle of the annotation is
+// returned.
+func markupAreas(dict *unicore.PdfObjectDictionary, rect Rect) []Rect {
+	var areas []Rect
+	if arr, ok := unicore.GetArray(dict.Get("QuadPoints")); ok {
+		if vals, err := arr.ToFloat64Array(); err == nil {
+			for i := 0; i+8 <= len(vals); i += 8 {
+				area := Rect{Llx: vals[i], Lly: vals[i+1], Urx: vals[i], Ury: vals[i+1]}
+				for j := i + 2; j < i+8; j += 2 {
+					area = area.union(Rect{Llx: vals[j], Lly: vals[j+1], Urx: vals[j], Ury: vals[j+1]})
+				}
+				areas = append(areas, area)
+			}
+		}
+	}
+	if len(areas) == 0 {
+		areas = append(areas, rect)
+	}
+
+	return areas
+}
+
+// areaText returns the text of the page covered by the specified areas.
+// A text mark is considered covered if its center is inside any of the areas.
+func (pt *PageText) areaText(areas []Rect) string {
+	var sb strings.Builder
+	var gap bool
+
+	for _, mark := range pt.Marks {
+		cx := (mark.BBox.Llx + mark.BBox.Urx) / 2
+		cy := (mark.BBox.Lly + mark.BBox.Ury) / 2
+
+		var covered bool
+		for _, area := range areas {
+			if cx >= area.Llx && cx <= area.Urx && cy >= area.Lly && cy <= area.Ury {
+				covered = true
+				break
+			}
+		}
+		if mark.Meta || !covered {
+			gap = true
+			continue
+		}
+
+		if gap && sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		gap = false
+
+		sb.WriteString(mark.Text)
+	}
+
+	return normalizeSpaces(sb.String())
+}
+
+// decodedString returns the decoded value of the specified string object.
+func decodedString(obj unicore.PdfObject) string {
+	str, ok := unicore.GetString(obj)
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(str.Decoded())
+}
+
+// annotationDate returns the specified PDF date in RFC 3339 format. If the
+// date cannot be parsed, it is returned as is.
+func annotationDate(obj unicore.PdfObject) string {
+	str := decodedString(obj)
+	if str == "" {
+		return ""
+	}
+
+	date, err := unipdf.NewPdfDate(str)
+	if err != nil {
+		return str
+	}
+
+	return date.ToGoTime().Format(time.RFC3339)
+}
+
+// EncodeAnnotations encodes the provided annotations using the specified
+// format. Supported formats are json, markdown and csv. In the JSON and
+// Markdown output, the replies are nested under the annotations they reply
+// to. In the CSV output, each annotation is written as a separate row.
+func EncodeAnnotations(annotations []*Annotation, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(annotationThreads(annotations), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return append(data, '\n'), nil
+	case "markdown":
+		return []byte(annotationsMarkdown(annotationThreads(annotations))), nil
+	case "csv":
+		records := [][]string{{
+			"id", "page", "type", "author", "subject", "created", "modified",
+			"contents", "text", "in_reply_to", "llx", "lly", "urx", "ury",
+		}}
+
+		formatFloat := func(val float64) string {
+			return strconv.FormatFloat(val, 'f', 2, 64)
+		}
+		for _, a := range annotations {
+			records = append(records, []string{
+				a.ID, strconv.Itoa(a.Page), a.Type, a.Author, a.Subject,
+				a.Created, a.Modified, a.Contents, a.Text, a.InReplyTo,
+				formatFloat(a.Rect.Llx), formatFloat(a.Rect.Lly),
+				formatFloat(a.Rect.Urx), formatFloat(a.Rect.Ury),
+			})
+		}
+
+		var sb strings.Builder
+		w := csv.NewWriter(&sb)
+		if err := w.WriteAll(records); err != nil {
+			return nil, err
+		}
+
+		return []byte(sb.String()), nil
+	}
+
+	return nil, fmt.Errorf("unsupported annotation format: %s", format)
+}
+
+// annotationThreads returns copies of the provided annotations, with the
+// replies nested under the annotations they reply to. The annotations which
+// are not replies, or which reply to annotations missing from the provided
+// list, are returned.
+func annotationThreads(annotations []*Annotation) []*Annotation {
+	ids := map[string]*Annotation{}
+	threads := make([]*Annotation, 0, len(annotations))
+	copies := make([]*Annotation, 0, len(annotations))
+
+	for _, a := range annotations {
+		c := *a
+		c.Replies = nil
+		copies = append(copies, &c)
+		if _, ok := ids[c.ID]; !ok {
+			ids[c.ID] = &c
+		}
+	}
+
+	for _, a := range copies {
+		if parent, ok := ids[a.InReplyTo]; ok && a.InReplyTo != "" && parent != a {
+			parent.Replies = append(parent.Replies, a)
+			continue
+		}
+
+		threads = append(threads, a)
+	}
+
+	return threads
+}
+
+// annotationsMarkdown returns the Markdown representation of the provided
+// annotation threads, grouped by page.
+func annotationsMarkdown(threads []*Annotation) string {
+	var sb strings.Builder
+
+	var writeAnnotation func(a *Annotation, indent string)
+	writeAnnotation = func(a *Annotation, indent string) {
+		header := "**" + a.Type + "**"
+		if a.Author != "" {
+			header += " by " + a.Author
+		}
+		if a.Created != "" {
+			header += " on " + a.Created
+		} else if a.Modified != "" {
+			header += " on " + a.Modified
+		}
+		if a.Subject != "" {
+			header += " (" + a.Subject + ")"
+		}
+		sb.WriteString(indent + "- " + header + "\n")
+
+		if a.Text != "" {
+			sb.WriteString(indent + "  > " + a.Text + "\n")
+		}
+		if a.Contents != "" {
+			if a.Text != "" {
+				sb.WriteString("\n")
+			}
+			for _, line := range strings.Split(a.Contents, "\n") {
+				sb.WriteString(indent + "  " + strings.TrimRight(line, "\r") + "\n")
+			}
+		}
+
+		for _, reply := range a.Replies {
+			writeAnnotation(reply, indent+"  ")
+		}
+	}
+
+	page := 0
+	for _, a := range threads {
+		if a.Page != page {
+			if page != 0 {
+				sb.WriteString("\n")
+			}
+			page = a.Page
+			sb.WriteString(fmt.Sprintf("## Page %d\n\n", page))
+		}
+
+		writeAnnotation(a, "")
+	}
+
+	return sb.String()
+}
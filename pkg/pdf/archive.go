@@ -0,0 +1,332 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OutputOpts represents the options used for saving a collection of files.
+type OutputOpts struct {
+	// Dir is the directory in which the files are saved. If specified, the
+	// files are saved in the directory, instead of an archive.
+	Dir string
+
+	// NameTemplate is the template used for naming the output files.
+	// Supported placeholders:
+	//   - {name}: the name of the input file, without extension
+	//   - {page}: the page number
+	//   - {index}: the index of the file on the page (e.g. image index)
+	//   - {ext}: the extension of the file
+	// The {page} and {index} placeholders support a width, which is always
+	// padded with zeros (e.g. {page:04} or {page:4}).
+	NameTemplate string
+}
+
+// writer returns a file writer for the specified output options. If no
+// output directory is specified, an archive writer is returned for the
+// provided output path. The function also returns the output location.
+func (o *OutputOpts) writer(outputPath string) (fileWriter, string, error) {
+	if o != nil && o.Dir != "" {
+		fw, err := newDirFileWriter(o.Dir)
+		return fw, o.Dir, err
+	}
+
+	fw, err := newArchiveWriter(outputPath)
+	return fw, outputPath, err
+}
+
+// namer returns a file namer for the specified output options. The provided
+// default template is used if no name template is specified.
+func (o *OutputOpts) namer(inputPath, defaultTemplate string) *fileNamer {
+	template := defaultTemplate
+	if o != nil && o.NameTemplate != "" {
+		template = o.NameTemplate
+	}
+
+	name := filepath.Base(inputPath)
+	return &fileNamer{
+		template: template,
+		name:     strings.TrimSuffix(name, filepath.Ext(name)),
+		names:    map[string]bool{},
+	}
+}
+
+// fileNamer generates the names of the output files, based on a name
+// template.
+type fileNamer struct {
+	template string
+	name     string
+	names    map[string]bool
+}
+
+var widthRegexp = regexp.MustCompile(`^\d+$`)
+
+// next returns the name of the file having the specified properties.
+// An error is returned if the name has already been generated, as the
+// files would overwrite each other.
+func (n *fileNamer) next(page, index int, ext string) (string, error) {
+	formatInt := func(val int, width string) string {
+		if !widthRegexp.MatchString(width) {
+			return strconv.Itoa(val)
+		}
+		w, _ := strconv.Atoi(width)
+		return fmt.Sprintf("%0*d", w, val)
+	}
+
+	name := templateRegexp.ReplaceAllStringFunc(n.template, func(match string) string {
+		groups := templateRegexp.FindStringSubmatch(match)
+
+		switch groups[1] {
+		case "name":
+			return n.name
+		case "page":
+			return formatInt(page, groups[2])
+		case "index":
+			return formatInt(index, groups[2])
+		case "ext":
+			return ext
+		}
+
+		return match
+	})
+
+	if n.names[name] {
+		return "", fmt.Errorf("duplicate output file name: %s", name)
+	}
+	n.names[name] = true
+
+	return name, nil
+}
+
+// fileWriter writes a collection of named files to an output location.
+type fileWriter interface {
+	// Create adds a file with the specified name to the output location
+	// and returns a writer for its content. The writer is valid until the
+	// next call to Create or Close.
+	Create(name string) (io.Writer, error)
+
+	// Close finalizes the output location.
+	Close() error
+}
+
+// newFileWriter returns a file writer for the specified output location.
+// If an output directory is specified, the files are written to it.
+// Otherwise, the files are written to an archive at the specified output
+// path.
+func newFileWriter(outputPath, outputDir string) (fileWriter, error) {
+	if outputDir != "" {
+		return newDirFileWriter(outputDir)
+	}
+
+	return newArchiveWriter(outputPath)
+}
+
+// newArchiveWriter returns an archive writer for the specified output path.
+// If the output path has the .tar extension, the files are written to a TAR
+// archive. If the output path has the .tar.gz or .tgz extension, the files
+// are written to a gzip compressed TAR archive. Otherwise, the files are
+// written to a ZIP archive.
+func newArchiveWriter(outputPath string) (fileWriter, error) {
+	path := strings.ToLower(outputPath)
+	switch {
+	case strings.HasSuffix(path, ".tar"):
+		return newTarFileWriter(outputPath, false)
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return newTarFileWriter(outputPath, true)
+	}
+
+	return newZipFileWriter(outputPath)
+}
+
+// writeFile adds a file with the specified name and content to the provided
+// file writer.
+func writeFile(fw fileWriter, name string, data []byte) error {
+	w, err := fw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// zipFileWriter writes files to a ZIP archive.
+type zipFileWriter struct {
+	file *os.File
+	w    *zip.Writer
+	now  time.Time
+}
+
+func newZipFileWriter(outputPath string) (*zipFileWriter, error) {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &zipFileWriter{
+		file: file,
+		w:    zip.NewWriter(file),
+		now:  time.Now(),
+	}, nil
+}
+
+// Create adds a file with the specified name to the ZIP archive.
+func (zw *zipFileWriter) Create(name string) (io.Writer, error) {
+	return zw.w.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: zw.now,
+	})
+}
+
+// Close finalizes the ZIP archive and closes the output file.
+func (zw *zipFileWriter) Close() error {
+	if err := zw.w.Close(); err != nil {
+		zw.file.Close()
+		return err
+	}
+
+	return zw.file.Close()
+}
+
+// tarFileWriter writes files to a TAR archive, optionally compressed using
+// gzip. As the size of each file must be known before writing it to the
+// archive, the content of the current file is buffered.
+type tarFileWriter struct {
+	file *os.File
+	gw   *gzip.Writer
+	w    *tar.Writer
+	now  time.Time
+
+	name string
+	buf  bytes.Buffer
+}
+
+func newTarFileWriter(outputPath string, compress bool) (*tarFileWriter, error) {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tw := &tarFileWriter{file: file, now: time.Now()}
+	if compress {
+		tw.gw = gzip.NewWriter(file)
+		tw.w = tar.NewWriter(tw.gw)
+	} else {
+		tw.w = tar.NewWriter(file)
+	}
+
+	return tw, nil
+}
+
+// Create adds a file with the specified name to the TAR archive.
+func (tw *tarFileWriter) Create(name string) (io.Writer, error) {
+	if err := tw.flush(); err != nil {
+		return nil, err
+	}
+	tw.name = name
+
+	return &tw.buf, nil
+}
+
+// Close finalizes the TAR archive and closes the output file.
+func (tw *tarFileWriter) Close() error {
+	err := tw.flush()
+	if err == nil {
+		err = tw.w.Close()
+	}
+	if err == nil && tw.gw != nil {
+		err = tw.gw.Close()
+	}
+	if err != nil {
+		tw.file.Close()
+		return err
+	}
+
+	return tw.file.Close()
+}
+
+// flush writes the buffered file to the TAR archive.
+func (tw *tarFileWriter) flush() error {
+	if tw.name == "" {
+		return nil
+	}
+
+	err := tw.w.WriteHeader(&tar.Header{
+		Name:    tw.name,
+		Mode:    0644,
+		Size:    int64(tw.buf.Len()),
+		ModTime: tw.now,
+	})
+	if err == nil {
+		_, err = tw.w.Write(tw.buf.Bytes())
+	}
+
+	tw.name = ""
+	tw.buf.Reset()
+	return err
+}
+
+// dirFileWriter writes files to a directory.
+type dirFileWriter struct {
+	dir  string
+	file *os.File
+}
+
+func newDirFileWriter(dir string) (*dirFileWriter, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &dirFileWriter{dir: dir}, nil
+}
+
+// Create creates a file with the specified name in the output directory.
+func (dw *dirFileWriter) Create(name string) (io.Writer, error) {
+	if err := dw.closeFile(); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dw.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	dw.file = file
+
+	return file, nil
+}
+
+// Close closes the last created file.
+func (dw *dirFileWriter) Close() error {
+	return dw.closeFile()
+}
+
+func (dw *dirFileWriter) closeFile() error {
+	if dw.file == nil {
+		return nil
+	}
+
+	err := dw.file.Close()
+	dw.file = nil
+	return err
+}
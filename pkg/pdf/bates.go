@@ -0,0 +1,162 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// BatesOpts represents the options used for stamping Bates numbers on the
+// pages of PDF files. A Bates number consists of a prefix, a zero-padded
+// counter and a suffix (e.g. ABC000123-CONF).
+type BatesOpts struct {
+	// Prefix specifies the text placed before the counter.
+	Prefix string
+
+	// Suffix specifies the text placed after the counter.
+	Suffix string
+
+	// Digits specifies the minimum number of digits of the counter. The
+	// counter is padded with zeros up to the specified width (default 6).
+	Digits int
+
+	// Position specifies the position of the Bates number on the page.
+	// Supported values: top-left, top, top-right, bottom-left, bottom,
+	// bottom-right (default).
+	Position string
+
+	// FontName specifies the name of the standard font used for drawing
+	// the Bates numbers (default Helvetica).
+	FontName string
+
+	// FontPath specifies the path of a TrueType font file used for drawing
+	// the Bates numbers. If specified, the FontName field is ignored.
+	FontPath string
+
+	// FontSize specifies the font size of the Bates numbers (default 10).
+	FontSize float64
+
+	// Color specifies the color of the Bates numbers as a hex string
+	// (default #000000).
+	Color string
+
+	// Margin specifies the distance between the Bates numbers and the edges
+	// of the page. If nil, the default margin of 36 points is used.
+	Margin *float64
+}
+
+// BatesResult contains information about the Bates numbers stamped on the
+// pages of a PDF file.
+type BatesResult struct {
+	// Pages is the number of stamped pages.
+	Pages int
+
+	// Next is the value of the counter following the last stamped page.
+	// It can be used as the start value of the next file in a document set.
+	Next int
+
+	// Begin is the Bates number of the first page of the file.
+	Begin string
+
+	// End is the Bates number of the last page of the file.
+	End string
+}
+
+// Bates stamps sequential Bates numbers on all the pages of the PDF file
+// specified by the inputPath parameter, starting with the counter value
+// specified by the start parameter. A password can be passed in for
+// encrypted input files. The resulting file is saved at the location
+// specified by the outputPath parameter.
+// In order to number a document set, the Next field of the returned result
+// should be used as the start value of the following file.
+func Bates(inputPath, outputPath, password string, start int, opts *BatesOpts) (*BatesResult, error) {
+	if opts == nil {
+		opts = &BatesOpts{}
+	}
+
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare stamp.
+	stampOpts := &StampOpts{
+		FontName: opts.FontName,
+		FontPath: opts.FontPath,
+		FontSize: opts.FontSize,
+		Color:    opts.Color,
+		Margin:   opts.Margin,
+	}
+
+	switch opts.Position {
+	case "top-left":
+		stampOpts.HeaderLeft = "{bates}"
+	case "top":
+		stampOpts.HeaderCenter = "{bates}"
+	case "top-right":
+		stampOpts.HeaderRight = "{bates}"
+	case "bottom-left":
+		stampOpts.FooterLeft = "{bates}"
+	case "bottom":
+		stampOpts.FooterCenter = "{bates}"
+	case "bottom-right", "":
+		stampOpts.FooterRight = "{bates}"
+	default:
+		return nil, fmt.Errorf("invalid Bates number position: %s", opts.Position)
+	}
+
+	stamp, err := newPageStamp(stampOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := stampVars{
+		total: pageCount,
+		file:  filepath.Base(inputPath),
+		date:  time.Now(),
+	}
+
+	// Stamp pages.
+	res := &BatesResult{Pages: pageCount, Next: start}
+
+	c, err := stamp.apply(r, pageCount, nil, vars, func(vars *stampVars) {
+		vars.bates = opts.format(res.Next)
+		if res.Begin == "" {
+			res.Begin = vars.bates
+		}
+		res.End = vars.bates
+		res.Next++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Add forms.
+	if r.AcroForm != nil {
+		c.SetForms(r.AcroForm)
+	}
+
+	// Write output file.
+	safe := inputPath == outputPath
+	if err = writeCreatorPDF(outputPath, c, safe); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// format returns the Bates number corresponding to the specified counter.
+func (opts *BatesOpts) format(counter int) string {
+	digits := opts.Digits
+	if digits <= 0 {
+		digits = 6
+	}
+
+	return fmt.Sprintf("%s%0*d%s", opts.Prefix, digits, counter, opts.Suffix)
+}
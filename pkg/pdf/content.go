@@ -0,0 +1,424 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"github.com/unidoc/unipdf/v4/common"
+	unicontent "github.com/unidoc/unipdf/v4/contentstream"
+	unicore "github.com/unidoc/unipdf/v4/core"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// textState represents the text state parameters of a content stream.
+type textState struct {
+	font        *unipdf.PdfFont
+	fontName    unicore.PdfObjectName
+	size        float64
+	charSpacing float64
+	wordSpacing float64
+	scale       float64
+	leading     float64
+	rise        float64
+}
+
+// contentState represents the state of a content stream at the position of
+// an operation.
+type contentState struct {
+	// resources contains the resources of the content stream.
+	resources *unipdf.PdfPageResources
+
+	// ctm is the current transformation matrix.
+	ctm matrix
+
+	// text contains the text state parameters, while tm and tlm are the text
+	// matrix and the text line matrix.
+	text    textState
+	tm, tlm matrix
+}
+
+// textGlyph represents a glyph shown by a text showing operation.
+type textGlyph struct {
+	// code contains the bytes of the character code of the glyph. The code
+	// is nil if the string containing the glyph cannot be split into the
+	// character codes of its glyphs.
+	code []byte
+
+	// text is the text of the glyph.
+	text string
+
+	// width is the width of the glyph and adv is its horizontal
+	// displacement, including the character and word spacing, in
+	// thousandths of a text space unit, as used by the TJ operator.
+	width float64
+	adv   float64
+
+	// trm is the text rendering matrix of the glyph.
+	trm matrix
+}
+
+// walkedContent represents a content stream processed by a content walker.
+type walkedContent struct {
+	ops       *unicontent.ContentStreamOperations
+	resources *unipdf.PdfPageResources
+
+	// forms contains the form XObjects drawn by the operations of the
+	// content stream, if the form XObjects are processed.
+	forms map[*unicontent.ContentStreamOperation]*walkedForm
+}
+
+// walkedForm represents a form XObject drawn by a content stream.
+type walkedForm struct {
+	stream  *unicore.PdfObjectStream
+	content *walkedContent
+}
+
+// contentWalker processes the operations of content streams, tracking the
+// transformation matrix and the text state. The form XObjects drawn by the
+// content streams are processed recursively, if enabled.
+type contentWalker struct {
+	// showText, if specified, is called for each string shown by the text
+	// showing operations. The index is the position of the string in the
+	// operation parameters or, for the TJ operator, in the shown array.
+	// The glyphs of the string are nil if the font cannot be loaded.
+	showText func(op *unicontent.ContentStreamOperation, index int, str *unicore.PdfObjectString,
+		glyphs []*textGlyph, state *contentState)
+
+	// drawImage, if specified, is called for each image drawn by the
+	// content streams. The stream of inline images is nil.
+	drawImage func(op *unicontent.ContentStreamOperation, stream *unicore.PdfObjectStream,
+		state *contentState) error
+
+	// walkForms specifies if the form XObjects are processed.
+	walkForms bool
+
+	// fonts caches the loaded fonts. forms contains the form XObjects which
+	// are currently processed, used in order to avoid cycles.
+	fonts map[unicore.PdfObject]*unipdf.PdfFont
+	forms map[*unicore.PdfObjectStream]bool
+}
+
+// walk processes the specified page content stream, using the provided
+// resources.
+func (w *contentWalker) walk(contents string, resources *unipdf.PdfPageResources) (*walkedContent, error) {
+	if w.fonts == nil {
+		w.fonts = map[unicore.PdfObject]*unipdf.PdfFont{}
+	}
+	if w.forms == nil {
+		w.forms = map[*unicore.PdfObjectStream]bool{}
+	}
+
+	return w.walkContent(contents, resources, identityMatrix, textState{scale: 1})
+}
+
+// walkContent processes the specified content stream, using the provided
+// resources, transformation matrix and text state.
+func (w *contentWalker) walkContent(contents string, resources *unipdf.PdfPageResources, ctm matrix,
+	ts textState) (*walkedContent, error) {
+	ops, err := unicontent.NewContentStreamParser(contents).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &walkedContent{
+		ops:       ops,
+		resources: resources,
+		forms:     map[*unicontent.ContentStreamOperation]*walkedForm{},
+	}
+
+	type graphicsState struct {
+		ctm matrix
+		ts  textState
+	}
+
+	var stack []graphicsState
+	state := &contentState{
+		resources: resources,
+		ctm:       ctm,
+		text:      ts,
+		tm:        identityMatrix,
+		tlm:       identityMatrix,
+	}
+	nextLine := func() {
+		state.tlm = matrix{1, 0, 0, 1, 0, -state.text.leading}.mult(state.tlm)
+		state.tm = state.tlm
+	}
+
+	for _, op := range *ops {
+		switch op.Operand {
+		case "q":
+			stack = append(stack, graphicsState{ctm: state.ctm, ts: state.text})
+		case "Q":
+			if len(stack) > 0 {
+				state.ctm, state.text = stack[len(stack)-1].ctm, stack[len(stack)-1].ts
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			vals, err := unicore.GetNumbersAsFloat(op.Params)
+			if err != nil || len(vals) != 6 {
+				continue
+			}
+			state.ctm = matrix{vals[0], vals[1], vals[2], vals[3], vals[4], vals[5]}.mult(state.ctm)
+		case "BT":
+			state.tm, state.tlm = identityMatrix, identityMatrix
+		case "Tf":
+			if len(op.Params) != 2 {
+				continue
+			}
+
+			name, ok := unicore.GetName(op.Params[0])
+			if !ok {
+				continue
+			}
+			state.text.font = w.font(resources, *name)
+			state.text.fontName = *name
+
+			if size, err := unicore.GetNumberAsFloat(op.Params[1]); err == nil {
+				state.text.size = size
+			}
+		case "Tc", "Tw", "Tz", "TL", "Ts":
+			if len(op.Params) != 1 {
+				continue
+			}
+
+			val, err := unicore.GetNumberAsFloat(op.Params[0])
+			if err != nil {
+				continue
+			}
+
+			switch op.Operand {
+			case "Tc":
+				state.text.charSpacing = val
+			case "Tw":
+				state.text.wordSpacing = val
+			case "Tz":
+				state.text.scale = val / 100
+			case "TL":
+				state.text.leading = val
+			case "Ts":
+				state.text.rise = val
+			}
+		case "Td", "TD":
+			vals, err := unicore.GetNumbersAsFloat(op.Params)
+			if err != nil || len(vals) != 2 {
+				continue
+			}
+			if op.Operand == "TD" {
+				state.text.leading = -vals[1]
+			}
+
+			state.tlm = matrix{1, 0, 0, 1, vals[0], vals[1]}.mult(state.tlm)
+			state.tm = state.tlm
+		case "Tm":
+			vals, err := unicore.GetNumbersAsFloat(op.Params)
+			if err != nil || len(vals) != 6 {
+				continue
+			}
+
+			state.tlm = matrix{vals[0], vals[1], vals[2], vals[3], vals[4], vals[5]}
+			state.tm = state.tlm
+		case "T*":
+			nextLine()
+		case "Tj", "'":
+			if len(op.Params) != 1 {
+				common.Log.Debug("Invalid: %s with invalid set of parameters - skip", op.Operand)
+				continue
+			}
+			if op.Operand == "'" {
+				nextLine()
+			}
+
+			w.showString(op, 0, op.Params[0], state)
+		case "''", `"`:
+			if len(op.Params) != 3 {
+				common.Log.Debug("Invalid: %s with invalid set of parameters - skip", op.Operand)
+				continue
+			}
+
+			vals, err := unicore.GetNumbersAsFloat(op.Params[:2])
+			if err != nil {
+				continue
+			}
+			state.text.wordSpacing, state.text.charSpacing = vals[0], vals[1]
+			nextLine()
+
+			w.showString(op, 2, op.Params[2], state)
+		case "TJ":
+			if len(op.Params) != 1 {
+				common.Log.Debug("Invalid: TJ with invalid set of parameters - skip")
+				continue
+			}
+
+			arr, ok := unicore.GetArray(op.Params[0])
+			if !ok {
+				continue
+			}
+
+			for i, elem := range arr.Elements() {
+				if val, err := unicore.GetNumberAsFloat(elem); err == nil {
+					ts := state.text
+					state.tm = matrix{1, 0, 0, 1, -val / 1000 * ts.size * ts.scale, 0}.mult(state.tm)
+					continue
+				}
+				w.showString(op, i, elem, state)
+			}
+		case "BI":
+			if w.drawImage != nil {
+				if err := w.drawImage(op, nil, state); err != nil {
+					return nil, err
+				}
+			}
+		case "Do":
+			if len(op.Params) != 1 || resources == nil {
+				continue
+			}
+
+			name, ok := unicore.GetName(op.Params[0])
+			if !ok {
+				continue
+			}
+
+			stream, xtype := resources.GetXObjectByName(*name)
+			switch xtype {
+			case unipdf.XObjectTypeImage:
+				if w.drawImage != nil {
+					if err := w.drawImage(op, stream, state); err != nil {
+						return nil, err
+					}
+				}
+			case unipdf.XObjectTypeForm:
+				if !w.walkForms || w.forms[stream] {
+					continue
+				}
+
+				xform, err := resources.GetXObjectFormByName(*name)
+				if err != nil {
+					return nil, err
+				}
+
+				formContents, err := xform.GetContentStream()
+				if err != nil {
+					return nil, err
+				}
+
+				formResources := xform.Resources
+				if formResources == nil {
+					formResources = resources
+				}
+
+				formMatrix := identityMatrix
+				if arr, ok := unicore.GetArray(xform.Matrix); ok {
+					if vals, err := arr.ToFloat64Array(); err == nil && len(vals) == 6 {
+						formMatrix = matrix{vals[0], vals[1], vals[2], vals[3], vals[4], vals[5]}
+					}
+				}
+
+				w.forms[stream] = true
+				content, err := w.walkContent(string(formContents), formResources,
+					formMatrix.mult(state.ctm), state.text)
+				delete(w.forms, stream)
+				if err != nil {
+					return nil, err
+				}
+
+				c.forms[op] = &walkedForm{stream: stream, content: content}
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// font returns the font having the specified name.
+func (w *contentWalker) font(resources *unipdf.PdfPageResources, name unicore.PdfObjectName) *unipdf.PdfFont {
+	if resources == nil {
+		return nil
+	}
+
+	obj, ok := resources.GetFontByName(name)
+	if !ok {
+		common.Log.Debug("ERROR: font %s not found", name)
+		return nil
+	}
+	if font, ok := w.fonts[obj]; ok {
+		return font
+	}
+
+	font, err := unipdf.NewPdfFontFromPdfObject(obj)
+	if err != nil {
+		common.Log.Debug("ERROR: could not load font %s: %v", name, err)
+	}
+	w.fonts[obj] = font
+
+	return font
+}
+
+// showString processes the glyphs of the provided string, shown by the
+// specified operation, and advances the text matrix past the string.
+func (w *contentWalker) showString(op *unicontent.ContentStreamOperation, index int, obj unicore.PdfObject,
+	state *contentState) {
+	str, ok := unicore.GetString(obj)
+	if !ok {
+		common.Log.Debug("Invalid parameter, skipping")
+		return
+	}
+
+	ts := state.text
+	if ts.font == nil {
+		if w.showText != nil {
+			w.showText(op, index, str, nil, state)
+		}
+		return
+	}
+
+	data := str.Bytes()
+	codes := ts.font.BytesToCharcodes(data)
+	texts, _, _ := ts.font.CharcodesToStrings(codes)
+
+	// The string can only be split if each fixed length byte sequence maps
+	// back to the character code of a glyph.
+	var codeLen int
+	if len(codes) > 0 && len(data)%len(codes) == 0 {
+		codeLen = len(data) / len(codes)
+	}
+
+	glyphs := make([]*textGlyph, 0, len(codes))
+	for i, code := range codes {
+		g := &textGlyph{}
+		if i < len(texts) {
+			g.text = texts[i]
+		}
+		if codeLen > 0 {
+			g.code = data[i*codeLen : (i+1)*codeLen]
+			if decoded := ts.font.BytesToCharcodes(g.code); len(decoded) != 1 || decoded[0] != code {
+				g.code = nil
+			}
+		}
+
+		if metrics, ok := ts.font.GetCharMetrics(code); ok {
+			g.width = metrics.Wx
+		}
+
+		// Word spacing only applies to the single byte code 32.
+		spacing := ts.charSpacing
+		if len(data) == len(codes) && data[i] == ' ' {
+			spacing += ts.wordSpacing
+		}
+
+		g.adv = g.width
+		if ts.size != 0 {
+			g.adv += spacing * 1000 / ts.size
+		}
+
+		g.trm = matrix{ts.size * ts.scale, 0, 0, ts.size, 0, ts.rise}.mult(state.tm).mult(state.ctm)
+		glyphs = append(glyphs, g)
+
+		state.tm = matrix{1, 0, 0, 1, (g.width/1000*ts.size + spacing) * ts.scale, 0}.mult(state.tm)
+	}
+
+	if w.showText != nil {
+		w.showText(op, index, str, glyphs, state)
+	}
+}
@@ -6,9 +6,6 @@
 package pdf
 
 import (
-	"archive/zip"
-	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -21,6 +18,17 @@ import (
 // A password can be passed in, if the input file is encrypted.
 // If the pages parameter is nil or an empty slice, all pages are extracted.
 func Explode(inputPath, outputPath, password string, pages []int) (string, error) {
+	return ExplodeWithOpts(inputPath, outputPath, password, pages, nil)
+}
+
+// ExplodeWithOpts splits the PDF file specified by the inputPath parameter
+// into single page PDF files. The extracted collection of PDF files is saved
+// as a ZIP or TAR archive at the location specified by the outputPath
+// parameter, or in the output directory specified by the opts parameter.
+// A password can be passed in, if the input file is encrypted.
+// If the pages parameter is nil or an empty slice, all pages are extracted.
+// The function returns the output location.
+func ExplodeWithOpts(inputPath, outputPath, password string, pages []int, opts *OutputOpts) (string, error) {
 	dir, inputFile := filepath.Split(inputPath)
 	// Use input file directory if no output path is specified.
 	inputFile = strings.TrimSuffix(inputFile, filepath.Ext(inputFile))
@@ -34,35 +42,43 @@ func Explode(inputPath, outputPath, password string, pages []int) (string, error
 		return "", err
 	}
 
-	// Prepare output archive.
-	outputFile, err := os.Create(outputPath)
+	// Prepare output location.
+	fw, outputPath, err := opts.writer(outputPath)
 	if err != nil {
 		return "", err
 	}
-	defer outputFile.Close()
 
 	// Extract pages.
 	if len(pages) == 0 {
 		pages = createPageRange(pageCount)
 	}
 
-	zw := zip.NewWriter(outputFile)
+	namer := opts.namer(inputPath, "{name}_{page}.{ext}")
 	for _, numPage := range pages {
 		w := unipdf.NewPdfWriter()
 		if err := readerToWriter(r, &w, []int{numPage}); err != nil {
+			fw.Close()
+			return "", err
+		}
+
+		// Add page to output location.
+		name, err := namer.next(numPage, 0, "pdf")
+		if err != nil {
+			fw.Close()
 			return "", err
 		}
 
-		// Add page to zip file.
-		file, err := zw.Create(fmt.Sprintf("%s_%d.pdf", inputFile, numPage))
+		file, err := fw.Create(name)
 		if err != nil {
+			fw.Close()
 			return "", err
 		}
 
 		if err = w.Write(file); err != nil {
+			fw.Close()
 			return "", err
 		}
 	}
 
-	return outputPath, zw.Close()
+	return outputPath, fw.Close()
 }
@@ -6,15 +6,11 @@
 package pdf
 
 import (
-	"archive/zip"
-	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
-	"image/jpeg"
-	"io"
-	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	uniextractor "github.com/unidoc/unipdf/v4/extractor"
 )
@@ -61,6 +57,68 @@ func ExtractText(inputPath, password string, pages []int) (string, error) {
 	return text, nil
 }
 
+// ExtractTextPages returns the text content of the PDF file specified by the
+// inputPath parameter, along with the position, font and color of each text
+// mark and line, grouped by page. A password can be specified for encrypted
+// PDF files. Also, a list of pages from which to extract text can be passed in.
+// If the pages parameter is nil or an empty slice, the text is extracted from
+// all the pages of the file.
+func ExtractTextPages(inputPath, password string, pages []int) ([]*PageText, error) {
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract text.
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
+
+	var pageTexts []*PageText
+	for _, numPage := range pages {
+		// Get page.
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return nil, err
+		}
+
+		// Extract page text.
+		pageText, err := newPageText(page, numPage)
+		if err != nil {
+			return nil, err
+		}
+
+		pageTexts = append(pageTexts, pageText)
+	}
+
+	return pageTexts, nil
+}
+
+// WriteTextPages saves the text of each of the provided pages as a separate
+// text file, named after the page number (e.g. p3.txt). If the layout
+// parameter is true, the physical layout of the pages is preserved.
+// The files are saved in a ZIP archive at the location specified by the
+// outputPath parameter, or in a TAR archive if the outputPath parameter has
+// the .tar, .tar.gz or .tgz extension. If the outputDir parameter is
+// specified, the files are saved in the output directory instead.
+func WriteTextPages(outputPath, outputDir string, pageTexts []*PageText, layout bool) error {
+	fw, err := newFileWriter(outputPath, outputDir)
+	if err != nil {
+		return err
+	}
+
+	for _, pageText := range pageTexts {
+		name := fmt.Sprintf("p%d.txt", pageText.Page)
+		if err := writeFile(fw, name, []byte(pageText.PlainText(layout))); err != nil {
+			fw.Close()
+			return err
+		}
+	}
+
+	return fw.Close()
+}
+
 // ExtractImages extracts all image content from the PDF file specified by the
 // inputPath parameter. The extracted collection of images is saved as a ZIP
 // archive at the location specified by the outputPath parameter.
@@ -73,6 +131,36 @@ func ExtractText(inputPath, password string, pages []int) (string, error) {
 // extraction options are used.
 func ExtractImages(inputPath, outputPath, password string, pages []int,
 	options *uniextractor.ImageExtractOptions) (string, int, error) {
+	opts := &ExtractImagesOpts{}
+	if options != nil {
+		opts.IncludeInlineStencilMasks = options.IncludeInlineStencilMasks
+	}
+
+	return ExtractImagesWithOpts(inputPath, outputPath, password, pages, opts)
+}
+
+// ExtractImagesWithOpts extracts all image content from the PDF file
+// specified by the inputPath parameter. The extracted images are saved in a
+// ZIP archive at the location specified by the outputPath parameter. If the
+// outputPath parameter has the .tar, .tar.gz or .tgz extension, the images
+// are saved in a TAR archive instead. If no output path is specified, the
+// ZIP archive is saved in the same directory as the input file.
+// Alternatively, the images can be saved in the output directory specified
+// by the opts parameter.
+// A password can be passed in, if the input file is encrypted.
+// Also, a list of pages from which to extract images can be passed in.
+// If the pages parameter is nil or an empty slice, the images are extracted
+// from all the pages of the file.
+// In addition, the image extraction process can be controlled by using the
+// opts parameter. If the opts parameter is nil, the default image extraction
+// options are used.
+// The function returns the output path and the number of extracted images.
+func ExtractImagesWithOpts(inputPath, outputPath, password string, pages []int,
+	opts *ExtractImagesOpts) (string, int, error) {
+	if opts == nil {
+		opts = &ExtractImagesOpts{}
+	}
+
 	// Use input file directory if no output path is specified.
 	if outputPath == "" {
 		dir, name := filepath.Split(inputPath)
@@ -91,11 +179,11 @@ func ExtractImages(inputPath, outputPath, password string, pages []int,
 		pages = createPageRange(pageCount)
 	}
 
-	// Create zip file.
-	zipBuffer := bytes.NewBuffer(nil)
-	w := zip.NewWriter(zipBuffer)
-	now := time.Now()
-	var countImages int
+	var fw fileWriter
+	namer := opts.namer(inputPath, "p{page}_{index}.{ext}")
+	var manifest []ImageInfo
+	hashes := map[[sha256.Size]byte]string{}
+	extractor := newImageExtractor(opts)
 
 	for _, numPage := range pages {
 		// Get page.
@@ -105,57 +193,70 @@ func ExtractImages(inputPath, outputPath, password string, pages []int,
 		}
 
 		// Extract page images.
-		extractor, err := uniextractor.New(page)
-		if err != nil {
-			return "", 0, err
-		}
-
-		pageImages, err := extractor.ExtractPageImages(options)
+		images, err := extractor.extract(page, numPage)
 		if err != nil {
 			return "", 0, err
 		}
 
-		// Add images to zip file.
-		images := pageImages.Images
-		countImages += len(images)
-
-		for i, pageImage := range images {
-			img, err := pageImage.Image.ToGoImage()
-			if err != nil {
+		for i, image := range images {
+			info := image.info
+			if info.File, err = namer.next(numPage, i, image.file.ext); err != nil {
+				if fw != nil {
+					fw.Close()
+				}
 				return "", 0, err
 			}
 
-			filename, err := w.CreateHeader(&zip.FileHeader{
-				Name:     (fmt.Sprintf("p%d_%d.jpg", numPage, i)),
-				Modified: now,
-			})
-			if err != nil {
-				return "", 0, err
+			// Skip images which have already been saved.
+			if opts.Dedupe {
+				hash := sha256.Sum256(image.file.data)
+				if name, ok := hashes[hash]; ok {
+					info.File = name
+					manifest = append(manifest, info)
+					continue
+				}
+				hashes[hash] = info.File
+			}
+
+			// Create the output file writer when the first image is found.
+			if fw == nil {
+				if fw, outputPath, err = opts.writer(outputPath); err != nil {
+					return "", 0, err
+				}
 			}
 
-			err = jpeg.Encode(filename, img, &jpeg.Options{Quality: 100})
-			if err != nil {
+			if err := writeFile(fw, info.File, image.file.data); err != nil {
+				fw.Close()
 				return "", 0, err
 			}
+
+			manifest = append(manifest, info)
 		}
 	}
 
-	if err := w.Close(); err != nil {
+	if fw == nil {
 		return "", 0, nil
 	}
-
-	if countImages == 0 {
-		return "", 0, nil
+	countImages := len(manifest)
+	if opts.Dedupe {
+		countImages = len(hashes)
 	}
 
-	// Write output file.
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return "", 0, err
+	// Write manifest file.
+	if opts.Manifest {
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			fw.Close()
+			return "", 0, err
+		}
+
+		if err := writeFile(fw, "manifest.json", data); err != nil {
+			fw.Close()
+			return "", 0, err
+		}
 	}
-	defer outputFile.Close()
 
-	if _, err := io.Copy(outputFile, zipBuffer); err != nil {
+	if err := fw.Close(); err != nil {
 		return "", 0, err
 	}
 
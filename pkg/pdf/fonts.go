@@ -0,0 +1,358 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	unicore "github.com/unidoc/unipdf/v4/core"
+)
+
+// FontInfo contains information about a font used in a PDF file.
+type FontInfo struct {
+	// File is the name of the file the font program is saved as. The field
+	// is empty if the font is not embedded.
+	File string `json:"file,omitempty"`
+
+	// Name is the base font name, without the subset prefix.
+	Name string `json:"name"`
+
+	// SubsetPrefix is the tag identifying a font subset (e.g. ABCDEF).
+	SubsetPrefix string `json:"subset_prefix,omitempty"`
+
+	// Type is the subtype of the font (e.g. Type1, TrueType, Type0).
+	Type string `json:"type"`
+
+	// Encoding is the encoding of the font.
+	Encoding string `json:"encoding,omitempty"`
+
+	// Embedded specifies if the font program is embedded in the file.
+	Embedded bool `json:"embedded"`
+
+	// Pages contains the numbers of the pages the font is used on.
+	Pages []int `json:"pages"`
+}
+
+var subsetRegexp = regexp.MustCompile(`^([A-Z]{6})\+(.+)$`)
+
+// ExtractFonts extracts the embedded font programs of the PDF file specified
+// by the inputPath parameter. Type 1 fonts are saved as .pfb files, TrueType
+// fonts as .ttf files, compact (CFF) fonts as .cff files and OpenType fonts
+// as .otf files. A fonts.json file, containing the information of all the
+// fonts used in the file, including the fonts which are not embedded, is
+// saved along with the font programs.
+// The files are saved in a ZIP archive at the location specified by the
+// outputPath parameter, or in a TAR archive if the outputPath parameter has
+// the .tar, .tar.gz or .tgz extension. If the outputDir parameter is
+// specified, the files are saved in the output directory instead. If no
+// output location is specified, the files are saved in a ZIP archive in the
+// same directory as the input file.
+// A password can be passed in, if the input file is encrypted.
+// Also, a list of pages from which to extract fonts can be passed in.
+// If the pages parameter is nil or an empty slice, the fonts are extracted
+// from all the pages of the file.
+// The function returns the output location and the information of the
+// fonts.
+func ExtractFonts(inputPath, outputPath, outputDir, password string, pages []int) (string, []*FontInfo, error) {
+	// Use input file directory if no output location is specified.
+	if outputDir != "" {
+		outputPath = outputDir
+	} else if outputPath == "" {
+		dir, name := filepath.Split(inputPath)
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + "_fonts.zip"
+		outputPath = filepath.Join(dir, name)
+	}
+
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Collect fonts.
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
+
+	collector := &fontCollector{
+		fonts: map[*unicore.PdfObjectDictionary]*pageFont{},
+	}
+	for _, numPage := range pages {
+		// Get page.
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return "", nil, err
+		}
+		if page.Resources == nil {
+			continue
+		}
+
+		collector.page = numPage
+		collector.visited = map[*unicore.PdfObjectDictionary]bool{}
+		collector.collect(page.Resources.Font, page.Resources.XObject)
+	}
+
+	if len(collector.order) == 0 {
+		return "", nil, nil
+	}
+
+	// Write font programs.
+	fw, err := newFileWriter(outputPath, outputDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	names := map[string]bool{}
+	infos := make([]*FontInfo, 0, len(collector.order))
+	for _, font := range collector.order {
+		info := font.info
+		infos = append(infos, info)
+		if font.program == nil {
+			continue
+		}
+
+		// Generate a unique file name.
+		base := fileNameRegexp.ReplaceAllString(font.baseFont, "_")
+		name := base + "." + font.program.ext
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%s_%d.%s", base, i, font.program.ext)
+		}
+		names[name] = true
+		info.File = name
+
+		if err := writeFile(fw, name, font.program.data); err != nil {
+			fw.Close()
+			return "", nil, err
+		}
+	}
+
+	// Write font information.
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		fw.Close()
+		return "", nil, err
+	}
+
+	if err := writeFile(fw, "fonts.json", data); err != nil {
+		fw.Close()
+		return "", nil, err
+	}
+
+	if err := fw.Close(); err != nil {
+		return "", nil, err
+	}
+
+	return outputPath, infos, nil
+}
+
+var fileNameRegexp = regexp.MustCompile(`[^\w+.-]`)
+
+// pageFont represents a font found in the resources of a page.
+type pageFont struct {
+	baseFont string
+	info     *FontInfo
+	program  *fontProgram
+}
+
+// fontProgram represents an embedded font program.
+type fontProgram struct {
+	ext  string
+	data []byte
+}
+
+// fontCollector collects the fonts used by the pages of a file, including
+// the fonts used by the form XObjects drawn on the pages.
+type fontCollector struct {
+	fonts map[*unicore.PdfObjectDictionary]*pageFont
+	order []*pageFont
+
+	// page is the number of the currently processed page.
+	page int
+
+	// visited contains the form XObjects processed for the current page.
+	visited map[*unicore.PdfObjectDictionary]bool
+}
+
+// collect collects the fonts from the specified font and XObject resources.
+func (c *fontCollector) collect(fontRes, xobjectRes unicore.PdfObject) {
+	if fonts, ok := unicore.GetDict(fontRes); ok {
+		for _, key := range fonts.Keys() {
+			fontDict, ok := unicore.GetDict(fonts.Get(key))
+			if !ok {
+				continue
+			}
+
+			font, ok := c.fonts[fontDict]
+			if !ok {
+				font = newPageFont(fontDict)
+				c.fonts[fontDict] = font
+				c.order = append(c.order, font)
+			}
+
+			if pages := font.info.Pages; len(pages) == 0 || pages[len(pages)-1] != c.page {
+				font.info.Pages = append(font.info.Pages, c.page)
+			}
+		}
+	}
+
+	xobjects, ok := unicore.GetDict(xobjectRes)
+	if !ok {
+		return
+	}
+
+	for _, key := range xobjects.Keys() {
+		stream, ok := unicore.GetStream(xobjects.Get(key))
+		if !ok || c.visited[stream.PdfObjectDictionary] {
+			continue
+		}
+		c.visited[stream.PdfObjectDictionary] = true
+
+		if subtype, _ := unicore.GetNameVal(stream.Get("Subtype")); subtype != "Form" {
+			continue
+		}
+
+		if res, ok := unicore.GetDict(stream.Get("Resources")); ok {
+			c.collect(res.Get("Font"), res.Get("XObject"))
+		}
+	}
+}
+
+// newPageFont returns the information and the embedded program of the
+// specified font.
+func newPageFont(fontDict *unicore.PdfObjectDictionary) *pageFont {
+	baseFont, _ := unicore.GetNameVal(fontDict.Get("BaseFont"))
+	subtype, _ := unicore.GetNameVal(fontDict.Get("Subtype"))
+
+	info := &FontInfo{
+		Name:     baseFont,
+		Type:     subtype,
+		Encoding: fontEncoding(fontDict.Get("Encoding")),
+	}
+	if groups := subsetRegexp.FindStringSubmatch(baseFont); groups != nil {
+		info.SubsetPrefix = groups[1]
+		info.Name = groups[2]
+	}
+
+	// The font descriptor of composite fonts is specified by the
+	// descendant font.
+	descFont := fontDict
+	if subtype == "Type0" {
+		if arr, ok := unicore.GetArray(fontDict.Get("DescendantFonts")); ok && arr.Len() > 0 {
+			if dict, ok := unicore.GetDict(arr.Get(0)); ok {
+				descFont = dict
+			}
+		}
+	}
+
+	font := &pageFont{baseFont: baseFont, info: info}
+	if baseFont == "" {
+		font.baseFont = "font"
+	}
+
+	if descriptor, ok := unicore.GetDict(descFont.Get("FontDescriptor")); ok {
+		font.program = embeddedFontProgram(descriptor)
+		info.Embedded = font.program != nil
+	}
+
+	return font
+}
+
+// fontEncoding returns the name of the specified font encoding.
+func fontEncoding(obj unicore.PdfObject) string {
+	obj = unicore.TraceToDirectObject(obj)
+	if name, ok := unicore.GetNameVal(obj); ok {
+		return name
+	}
+	if _, ok := obj.(*unicore.PdfObjectStream); ok {
+		return "Embedded CMap"
+	}
+
+	if dict, ok := unicore.GetDict(obj); ok {
+		encoding, ok := unicore.GetNameVal(dict.Get("BaseEncoding"))
+		if !ok {
+			encoding = "StandardEncoding"
+		}
+		if dict.Get("Differences") != nil {
+			encoding += " with differences"
+		}
+
+		return encoding
+	}
+
+	return ""
+}
+
+// embeddedFontProgram returns the font program embedded in the specified
+// font descriptor. If the font is not embedded, nil is returned.
+func embeddedFontProgram(descriptor *unicore.PdfObjectDictionary) *fontProgram {
+	if stream, ok := unicore.GetStream(descriptor.Get("FontFile")); ok {
+		data, err := unicore.DecodeStream(stream)
+		if err != nil {
+			return nil
+		}
+
+		return &fontProgram{ext: "pfb", data: type1ToPFB(stream, data)}
+	}
+
+	if stream, ok := unicore.GetStream(descriptor.Get("FontFile2")); ok {
+		data, err := unicore.DecodeStream(stream)
+		if err != nil {
+			return nil
+		}
+
+		return &fontProgram{ext: "ttf", data: data}
+	}
+
+	if stream, ok := unicore.GetStream(descriptor.Get("FontFile3")); ok {
+		data, err := unicore.DecodeStream(stream)
+		if err != nil {
+			return nil
+		}
+
+		ext := "cff"
+		if subtype, _ := unicore.GetNameVal(stream.Get("Subtype")); subtype == "OpenType" {
+			ext = "otf"
+		}
+
+		return &fontProgram{ext: ext, data: data}
+	}
+
+	return nil
+}
+
+// type1ToPFB converts the specified Type 1 font program to the PFB format.
+// The font program consists of a cleartext, a binary and a trailer segment,
+// the lengths of which are specified by the Length1, Length2 and Length3
+// entries of the font stream.
+func type1ToPFB(stream *unicore.PdfObjectStream, data []byte) []byte {
+	len1, _ := unicore.GetIntVal(stream.Get("Length1"))
+	len2, _ := unicore.GetIntVal(stream.Get("Length2"))
+	if len1 <= 0 || len2 < 0 || len1+len2 > len(data) {
+		return data
+	}
+
+	var buf bytes.Buffer
+	writeSegment := func(segType byte, segment []byte) {
+		buf.Write([]byte{0x80, segType})
+		binary.Write(&buf, binary.LittleEndian, uint32(len(segment)))
+		buf.Write(segment)
+	}
+
+	writeSegment(1, data[:len1])
+	writeSegment(2, data[len1:len1+len2])
+	if trailer := data[len1+len2:]; len(trailer) > 0 {
+		writeSegment(1, trailer)
+	}
+	buf.Write([]byte{0x80, 3})
+
+	return buf.Bytes()
+}
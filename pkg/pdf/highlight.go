@@ -0,0 +1,137 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	unicontent "github.com/unidoc/unipdf/v4/contentstream"
+	unicore "github.com/unidoc/unipdf/v4/core"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// HighlightOpts represents the options used for highlighting search matches.
+type HighlightOpts struct {
+	// Color is the color of the highlight annotations, in hex format
+	// (e.g. #ffff00). Defaults to yellow.
+	Color string
+
+	// Comment is the text of the highlight annotations. If empty, the
+	// annotations have no text.
+	Comment string
+}
+
+// Highlight adds a highlight annotation over each of the provided search
+// matches and saves the result to the file specified by the outputPath
+// parameter. The search results must have been obtained by searching the
+// file specified by the inputPath parameter, as the annotations are placed
+// using the bounding boxes of the matches. Matches spanning multiple lines
+// are covered by a single annotation. A password can be passed in for
+// encrypted input files.
+func Highlight(inputPath, outputPath, password string, results []*SearchResult, opts *HighlightOpts) error {
+	if opts == nil {
+		opts = &HighlightOpts{}
+	}
+
+	color, err := parseColor(opts.Color, [3]float64{1, 1, 0})
+	if err != nil {
+		return err
+	}
+
+	// Read input file.
+	r, _, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return err
+	}
+
+	// Add highlight annotations.
+	for _, result := range results {
+		page, err := r.GetPage(result.Page)
+		if err != nil {
+			return err
+		}
+
+		for _, match := range result.Matches {
+			if len(match.BBoxes) == 0 {
+				continue
+			}
+
+			annot, err := newHighlightAnnotation(match.BBoxes, color, opts.Comment)
+			if err != nil {
+				return err
+			}
+
+			page.AddAnnotation(annot.PdfAnnotation)
+		}
+	}
+
+	// Copy input file contents.
+	w := unipdf.NewPdfWriter()
+	if err := readerToWriter(r, &w, nil); err != nil {
+		return err
+	}
+
+	// Write output file.
+	safe := inputPath == outputPath
+	return writePDF(outputPath, &w, safe)
+}
+
+// newHighlightAnnotation returns a highlight annotation covering the
+// specified areas. Along with the quadrilaterals of the areas, the
+// annotation includes an appearance stream, used by the viewers which do not
+// generate the appearance of highlight annotations.
+func newHighlightAnnotation(areas []Rect, color [3]float64, comment string) (*unipdf.PdfAnnotationHighlight, error) {
+	rect := areas[0]
+	quadPoints := make([]float64, 0, 8*len(areas))
+	for _, area := range areas {
+		rect = rect.union(area)
+
+		// The corners are specified in the order used by most viewers
+		// (upper left, upper right, lower left, lower right), which differs
+		// from the order described by the PDF specification.
+		quadPoints = append(quadPoints,
+			area.Llx, area.Ury, area.Urx, area.Ury,
+			area.Llx, area.Lly, area.Urx, area.Lly,
+		)
+	}
+
+	// Create appearance stream.
+	extGState := unicore.MakeDict()
+	extGState.Set("BM", unicore.MakeName("Multiply"))
+
+	res := unipdf.NewPdfPageResources()
+	if err := res.AddExtGState("GSHighlight", extGState); err != nil {
+		return nil, err
+	}
+
+	cc := unicontent.NewContentCreator()
+	cc.Add_gs("GSHighlight").Add_rg(color[0], color[1], color[2])
+	for _, area := range areas {
+		cc.Add_re(area.Llx, area.Lly, area.Width(), area.Height())
+	}
+	cc.Add_f()
+
+	xform := unipdf.NewXObjectForm()
+	xform.Resources = res
+	xform.BBox = unicore.MakeArrayFromFloats([]float64{rect.Llx, rect.Lly, rect.Urx, rect.Ury})
+	if err := xform.SetContentStream(cc.Bytes(), unicore.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+
+	appearance := unicore.MakeDict()
+	appearance.Set("N", xform.ToPdfObject())
+
+	// Create annotation.
+	annot := unipdf.NewPdfAnnotationHighlight()
+	annot.Rect = unicore.MakeArrayFromFloats([]float64{rect.Llx, rect.Lly, rect.Urx, rect.Ury})
+	annot.QuadPoints = unicore.MakeArrayFromFloats(quadPoints)
+	annot.C = unicore.MakeArrayFromFloats(color[:])
+	annot.F = unicore.MakeInteger(4)
+	annot.AP = appearance
+	if comment != "" {
+		annot.Contents = unicore.MakeEncodedString(comment, true)
+	}
+
+	return annot, nil
+}
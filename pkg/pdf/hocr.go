@@ -0,0 +1,118 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+)
+
+const hocrHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+`
+
+type hocrHTML struct {
+	XMLName xml.Name   `xml:"html"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Head    hocrHead   `xml:"head"`
+	Pages   []hocrElem `xml:"body>div"`
+}
+
+type hocrHead struct {
+	Title string     `xml:"title"`
+	Meta  []hocrMeta `xml:"meta"`
+}
+
+type hocrMeta struct {
+	Name      string `xml:"name,attr,omitempty"`
+	HTTPEquiv string `xml:"http-equiv,attr,omitempty"`
+	Content   string `xml:"content,attr"`
+}
+
+type hocrElem struct {
+	Class string     `xml:"class,attr"`
+	ID    string     `xml:"id,attr"`
+	Title string     `xml:"title,attr"`
+	Text  string     `xml:",chardata"`
+	Elems []hocrElem `xml:"span"`
+}
+
+// EncodeHOCR encodes the provided page texts as an hOCR document. Each page
+// is represented by an ocr_page element, containing the ocr_line and the
+// ocrx_word elements of the page, along with their bounding boxes.
+// The coordinates are expressed in points, relative to the top-left corner
+// of the visible area of the page. As the text is not the result of OCR,
+// the confidence of each word is set to 100.
+func EncodeHOCR(pageTexts []*PageText, title string) ([]byte, error) {
+	doc := hocrHTML{
+		Xmlns: "http://www.w3.org/1999/xhtml",
+		Head: hocrHead{
+			Title: title,
+			Meta: []hocrMeta{
+				{HTTPEquiv: "Content-Type", Content: "text/html;charset=utf-8"},
+				{Name: "ocr-system", Content: "unipdf"},
+				{Name: "ocr-capabilities", Content: "ocr_page ocr_line ocrx_word"},
+			},
+		},
+	}
+
+	for i, pageText := range pageTexts {
+		box := pageText.BBox
+		page := hocrElem{
+			Class: "ocr_page",
+			ID:    fmt.Sprintf("page_%d", pageText.Page),
+			Title: fmt.Sprintf("bbox 0 0 %d %d; ppageno %d",
+				roundInt(box.Width()), roundInt(box.Height()), i),
+		}
+
+		for j, line := range pageText.Lines {
+			words := line.words()
+			if len(words) == 0 {
+				continue
+			}
+
+			lineElem := hocrElem{
+				Class: "ocr_line",
+				ID:    fmt.Sprintf("line_%d_%d", pageText.Page, j+1),
+				Title: "bbox " + hocrBBox(line.BBox, box),
+			}
+
+			for k, word := range words {
+				lineElem.Elems = append(lineElem.Elems, hocrElem{
+					Class: "ocrx_word",
+					ID:    fmt.Sprintf("word_%d_%d_%d", pageText.Page, j+1, k+1),
+					Title: fmt.Sprintf("bbox %s; x_wconf 100", hocrBBox(word.bbox, box)),
+					Text:  word.text,
+				})
+			}
+
+			page.Elems = append(page.Elems, lineElem)
+		}
+
+		doc.Pages = append(doc.Pages, page)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", " ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte(hocrHeader), data...), '\n'), nil
+}
+
+// hocrBBox returns the hOCR representation of the specified rectangle,
+// relative to the top-left corner of the provided page box.
+func hocrBBox(r, box Rect) string {
+	return fmt.Sprintf("%d %d %d %d",
+		roundInt(r.Llx-box.Llx), roundInt(box.Ury-r.Ury),
+		roundInt(r.Urx-box.Llx), roundInt(box.Ury-r.Lly))
+}
+
+// roundInt rounds the provided value to the nearest integer.
+func roundInt(val float64) int {
+	return int(math.Round(val))
+}
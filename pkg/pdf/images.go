@@ -0,0 +1,529 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strings"
+
+	unicontent "github.com/unidoc/unipdf/v4/contentstream"
+	unicore "github.com/unidoc/unipdf/v4/core"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// ExtractImagesOpts represents the options used for extracting images.
+type ExtractImagesOpts struct {
+	// IncludeInlineStencilMasks specifies if inline stencil masks are
+	// extracted.
+	IncludeInlineStencilMasks bool
+
+	// Native specifies if the images are saved in their native format,
+	// whenever possible. DCT encoded images are saved as JPEG files and JPX
+	// encoded images are saved as JPEG 2000 files, using the original image
+	// data. All other images are decoded and saved using the format
+	// specified by the Format field.
+	Native bool
+
+	// Format is the format used for saving decoded images (jpeg or png).
+	// The default format is jpeg, unless the Native field is true, in which
+	// case the default format is png. The transparency of the images,
+	// defined by their soft masks and masks, is only preserved by the png
+	// format. Images saved as JPEG files, including the native ones, are
+	// always opaque.
+	Format string
+
+	// MinWidth and MinHeight specify the minimum size of the extracted
+	// images, in pixels. Smaller images are skipped.
+	MinWidth  int
+	MinHeight int
+
+	// Dedupe specifies if identical images are saved only once.
+	Dedupe bool
+
+	// Manifest specifies if a manifest.json file, containing information
+	// about each of the extracted images, is saved along with the images.
+	Manifest bool
+
+	// OutputOpts specifies the output directory and the naming of the
+	// extracted images.
+	OutputOpts
+}
+
+// ImageInfo contains information about an image drawn on a page.
+type ImageInfo struct {
+	// File is the name of the file the image is saved as.
+	File string `json:"file"`
+
+	// Page is the number of the page the image is drawn on.
+	Page int `json:"page"`
+
+	// BBox is the area of the page covered by the image.
+	BBox Rect `json:"bbox"`
+
+	// Width and Height represent the size of the image, in pixels.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// DPIX and DPIY represent the effective resolution of the image, based
+	// on its size on the page.
+	DPIX float64 `json:"dpi_x"`
+	DPIY float64 `json:"dpi_y"`
+
+	// ColorSpace is the name of the color space of the image.
+	ColorSpace string `json:"color_space,omitempty"`
+
+	// BitsPerComponent is the number of bits used to represent each color
+	// component of the image.
+	BitsPerComponent int `json:"bits_per_component"`
+
+	// Filter contains the filters applied to the original image data.
+	Filter string `json:"filter,omitempty"`
+}
+
+// imageFile represents an encoded image.
+type imageFile struct {
+	ext  string
+	data []byte
+}
+
+// encodeImage encodes the provided image using the specified format.
+// The alpha channel, if provided, is only applied to png images, as JPEG
+// images do not support transparency.
+func encodeImage(img *unipdf.Image, alpha *image.Alpha, format string) (*imageFile, error) {
+	goImg, err := img.ToGoImage()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		if alpha != nil {
+			goImg = applyAlpha(goImg, alpha)
+		}
+		if err := png.Encode(&buf, goImg); err != nil {
+			return nil, err
+		}
+
+		return &imageFile{ext: "png", data: buf.Bytes()}, nil
+	case "jpeg":
+		if err := jpeg.Encode(&buf, goImg, &jpeg.Options{Quality: 100}); err != nil {
+			return nil, err
+		}
+
+		return &imageFile{ext: "jpg", data: buf.Bytes()}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported image format: %s", format)
+}
+
+// applyAlpha returns a copy of the provided image, having the specified
+// alpha channel.
+func applyAlpha(img image.Image, alpha *image.Alpha) image.Image {
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			c.A = alpha.AlphaAt(x-bounds.Min.X, y-bounds.Min.Y).A
+			nrgba.SetNRGBA(x, y, c)
+		}
+	}
+
+	return nrgba
+}
+
+// imageAlpha returns the alpha channel of the image represented by the
+// specified stream, defined by its soft mask (SMask) or by its mask (Mask),
+// which can be either a stencil mask or a color key mask. The alpha channel
+// has the size of the provided decoded image. If the image is not masked,
+// nil is returned.
+func imageAlpha(stream *unicore.PdfObjectStream, img *unipdf.Image) (*image.Alpha, error) {
+	if smask, ok := unicore.GetStream(stream.Get("SMask")); ok {
+		return maskAlpha(smask, int(img.Width), int(img.Height), func(sample, maxSample uint32) uint8 {
+			return uint8(sample * 255 / maxSample)
+		})
+	}
+
+	if mask, ok := unicore.GetStream(stream.Get("Mask")); ok {
+		// Stencil masks paint the areas having the sample value 0, unless
+		// their decode array is inverted.
+		var painted uint32
+		if decode, ok := unicore.GetArray(mask.Get("Decode")); ok {
+			if vals, err := decode.ToFloat64Array(); err == nil && len(vals) == 2 && vals[0] > vals[1] {
+				painted = 1
+			}
+		}
+
+		return maskAlpha(mask, int(img.Width), int(img.Height), func(sample, _ uint32) uint8 {
+			if sample == painted {
+				return 255
+			}
+			return 0
+		})
+	}
+
+	if ranges, ok := unicore.GetArray(stream.Get("Mask")); ok {
+		return colorKeyAlpha(img, ranges), nil
+	}
+
+	return nil, nil
+}
+
+// maskAlpha returns an alpha channel of the specified size, based on the
+// samples of the provided mask image stream. The value function converts
+// the samples of the mask to alpha values. The mask is scaled to the size of
+// the alpha channel, as the size of the mask can differ from the size of the
+// masked image.
+func maskAlpha(stream *unicore.PdfObjectStream, width, height int,
+	value func(sample, maxSample uint32) uint8) (*image.Alpha, error) {
+	ximg, err := unipdf.NewXObjectImageFromStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	mask, err := ximg.ToImage()
+	if err != nil {
+		return nil, err
+	}
+
+	maskWidth, maskHeight := int(mask.Width), int(mask.Height)
+	samples := mask.GetSamples()
+	if width <= 0 || height <= 0 || maskWidth <= 0 || maskHeight <= 0 ||
+		mask.BitsPerComponent <= 0 || len(samples) < maskWidth*maskHeight {
+		return nil, nil
+	}
+
+	maxSample := uint32(1)<<uint(mask.BitsPerComponent) - 1
+	alpha := image.NewAlpha(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		maskY := y * maskHeight / height
+		for x := 0; x < width; x++ {
+			maskX := x * maskWidth / width
+			alpha.Pix[y*alpha.Stride+x] = value(samples[maskY*maskWidth+maskX], maxSample)
+		}
+	}
+
+	return alpha, nil
+}
+
+// colorKeyAlpha returns the alpha channel of the provided image, based on
+// the specified color key mask ranges. The pixels having all their color
+// components within the ranges are transparent.
+func colorKeyAlpha(img *unipdf.Image, ranges *unicore.PdfObjectArray) *image.Alpha {
+	width, height, numComponents := int(img.Width), int(img.Height), img.ColorComponents
+	samples := img.GetSamples()
+
+	vals, err := ranges.ToIntegerArray()
+	if err != nil || numComponents <= 0 || len(vals) != 2*numComponents ||
+		len(samples) < width*height*numComponents {
+		return nil
+	}
+
+	alpha := image.NewAlpha(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		masked := true
+		for c := 0; c < numComponents && masked; c++ {
+			sample := int(samples[i*numComponents+c])
+			masked = sample >= vals[2*c] && sample <= vals[2*c+1]
+		}
+		if !masked {
+			alpha.Pix[i] = 255
+		}
+	}
+
+	return alpha
+}
+
+// pageImage represents an encoded image drawn on a page.
+type pageImage struct {
+	file *imageFile
+	info ImageInfo
+}
+
+// imageExtractor extracts the images drawn on the pages of a file.
+type imageExtractor struct {
+	opts   *ExtractImagesOpts
+	format string
+
+	// cache contains the encoded image XObjects, as they are usually shared
+	// between pages.
+	cache map[*unicore.PdfObjectStream]*imageFile
+}
+
+func newImageExtractor(opts *ExtractImagesOpts) *imageExtractor {
+	format := opts.Format
+	if format == "" {
+		format = "jpeg"
+		if opts.Native {
+			format = "png"
+		}
+	}
+
+	return &imageExtractor{
+		opts:   opts,
+		format: format,
+		cache:  map[*unicore.PdfObjectStream]*imageFile{},
+	}
+}
+
+// extract returns the images drawn on the specified page, in drawing order.
+// The form XObjects drawn on the page are processed recursively.
+func (e *imageExtractor) extract(page *unipdf.PdfPage, numPage int) ([]*pageImage, error) {
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &imageWalker{imageExtractor: e, page: numPage}
+	walker := &contentWalker{drawImage: w.drawImage, walkForms: true}
+	if _, err := walker.walk(contents, page.Resources); err != nil {
+		return nil, err
+	}
+
+	return w.images, nil
+}
+
+// imageWalker collects the images drawn by the content streams of a page.
+type imageWalker struct {
+	*imageExtractor
+	page   int
+	images []*pageImage
+}
+
+// drawImage adds the image drawn by the specified operation to the collected
+// images. The stream of inline images is nil.
+func (w *imageWalker) drawImage(op *unicontent.ContentStreamOperation, stream *unicore.PdfObjectStream,
+	state *contentState) error {
+	if stream != nil {
+		return w.addImage(stream, state.ctm)
+	}
+	if len(op.Params) != 1 {
+		return nil
+	}
+
+	inlineImage, ok := op.Params[0].(*unicontent.ContentStreamInlineImage)
+	if !ok {
+		return nil
+	}
+
+	return w.addInlineImage(inlineImage, state.resources, state.ctm)
+}
+
+// addImage adds the specified image XObject to the collected images.
+func (w *imageWalker) addImage(stream *unicore.PdfObjectStream, ctm matrix) error {
+	ximg, err := unipdf.NewXObjectImageFromStream(stream)
+	if err != nil {
+		return err
+	}
+
+	var width, height, bpc int64
+	if ximg.Width != nil {
+		width = *ximg.Width
+	}
+	if ximg.Height != nil {
+		height = *ximg.Height
+	}
+	if ximg.BitsPerComponent != nil {
+		bpc = *ximg.BitsPerComponent
+	}
+	if !w.accept(width, height) {
+		return nil
+	}
+
+	info := w.newImageInfo(ctm, width, height, bpc)
+	if ximg.ColorSpace != nil {
+		info.ColorSpace = ximg.ColorSpace.String()
+	}
+	info.Filter = strings.Join(streamFilters(stream), ",")
+
+	file, ok := w.cache[stream]
+	if !ok {
+		if file, err = w.encodeXObject(ximg, stream); err != nil {
+			return err
+		}
+		w.cache[stream] = file
+	}
+
+	w.images = append(w.images, &pageImage{file: file, info: info})
+	return nil
+}
+
+// encodeXObject encodes the specified image XObject. In native mode, DCT
+// and JPX encoded images are saved using the original image data.
+func (w *imageWalker) encodeXObject(ximg *unipdf.XObjectImage, stream *unicore.PdfObjectStream) (*imageFile, error) {
+	if filters := streamFilters(stream); w.opts.Native && len(filters) == 1 {
+		switch filters[0] {
+		case unicore.StreamEncodingFilterNameDCT:
+			return &imageFile{ext: "jpg", data: stream.Stream}, nil
+		case unicore.StreamEncodingFilterNameJPX:
+			return &imageFile{ext: "jp2", data: stream.Stream}, nil
+		}
+	}
+
+	img, err := ximg.ToImage()
+	if err != nil {
+		return nil, err
+	}
+
+	var alpha *image.Alpha
+	if w.format == "png" {
+		if alpha, err = imageAlpha(stream, img); err != nil {
+			return nil, err
+		}
+	}
+
+	return encodeImage(img, alpha, w.format)
+}
+
+// addInlineImage adds the specified inline image to the collected images.
+func (w *imageWalker) addInlineImage(inlineImage *unicontent.ContentStreamInlineImage,
+	resources *unipdf.PdfPageResources, ctm matrix) error {
+	if mask, _ := unicore.GetBoolVal(inlineImage.ImageMask); mask && !w.opts.IncludeInlineStencilMasks {
+		return nil
+	}
+
+	img, err := inlineImage.ToImage(resources)
+	if err != nil {
+		return err
+	}
+	if !w.accept(img.Width, img.Height) {
+		return nil
+	}
+
+	info := w.newImageInfo(ctm, img.Width, img.Height, img.BitsPerComponent)
+	if cs, err := inlineImage.GetColorSpace(resources); err == nil && cs != nil {
+		info.ColorSpace = cs.String()
+	}
+	info.Filter = strings.Join(filterNames(inlineImage.Filter), ",")
+
+	file, err := encodeImage(img, nil, w.format)
+	if err != nil {
+		return err
+	}
+
+	w.images = append(w.images, &pageImage{file: file, info: info})
+	return nil
+}
+
+// accept returns true if an image having the specified size in pixels
+// should be extracted.
+func (w *imageWalker) accept(width, height int64) bool {
+	return width >= int64(w.opts.MinWidth) && height >= int64(w.opts.MinHeight)
+}
+
+// newImageInfo returns the information of an image having the specified
+// properties, drawn using the provided transformation matrix.
+func (w *imageWalker) newImageInfo(ctm matrix, width, height, bpc int64) ImageInfo {
+	info := ImageInfo{
+		Page:             w.page,
+		BBox:             ctm.unitBBox(),
+		Width:            int(width),
+		Height:           int(height),
+		BitsPerComponent: int(bpc),
+	}
+
+	// The image is mapped onto the unit square of the image space.
+	if sx := math.Hypot(ctm[0], ctm[1]); sx > 0 {
+		info.DPIX = math.Round(float64(width)*72/sx*100) / 100
+	}
+	if sy := math.Hypot(ctm[2], ctm[3]); sy > 0 {
+		info.DPIY = math.Round(float64(height)*72/sy*100) / 100
+	}
+
+	return info
+}
+
+// streamFilters returns the names of the filters applied to the specified
+// stream.
+func streamFilters(stream *unicore.PdfObjectStream) []string {
+	return filterNames(stream.Get("Filter"))
+}
+
+// filterNames returns the filter names contained by the specified filter
+// entry, which can be either a name or an array of names.
+func filterNames(obj unicore.PdfObject) []string {
+	obj = unicore.TraceToDirectObject(obj)
+	if name, ok := unicore.GetNameVal(obj); ok {
+		return []string{name}
+	}
+
+	var filters []string
+	if arr, ok := unicore.GetArray(obj); ok {
+		for _, elem := range arr.Elements() {
+			if name, ok := unicore.GetNameVal(elem); ok {
+				filters = append(filters, name)
+			}
+		}
+	}
+
+	return filters
+}
+
+// matrix represents a PDF transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identityMatrix = matrix{1, 0, 0, 1, 0, 0}
+
+// mult returns the result of multiplying the matrix with the provided one.
+func (m matrix) mult(o matrix) matrix {
+	return matrix{
+		m[0]*o[0] + m[1]*o[2],
+		m[0]*o[1] + m[1]*o[3],
+		m[2]*o[0] + m[3]*o[2],
+		m[2]*o[1] + m[3]*o[3],
+		m[4]*o[0] + m[5]*o[2] + o[4],
+		m[4]*o[1] + m[5]*o[3] + o[5],
+	}
+}
+
+// transform applies the matrix to the specified point.
+func (m matrix) transform(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// inverse returns the inverse of the matrix. The second return value is
+// false if the matrix is not invertible.
+func (m matrix) inverse() (matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 {
+		return matrix{}, false
+	}
+
+	a, b, c, d := m[3]/det, -m[1]/det, -m[2]/det, m[0]/det
+	return matrix{a, b, c, d, -(m[4]*a + m[5]*c), -(m[4]*b + m[5]*d)}, true
+}
+
+// unitBBox returns the bounding box of the unit square, transformed using
+// the matrix.
+func (m matrix) unitBBox() Rect {
+	return m.transformRect(Rect{Llx: 0, Lly: 0, Urx: 1, Ury: 1})
+}
+
+// transformRect returns the bounding box of the specified rectangle,
+// transformed using the matrix.
+func (m matrix) transformRect(rect Rect) Rect {
+	var bbox Rect
+	for i, p := range [][2]float64{
+		{rect.Llx, rect.Lly}, {rect.Urx, rect.Lly}, {rect.Llx, rect.Ury}, {rect.Urx, rect.Ury},
+	} {
+		x, y := m.transform(p[0], p[1])
+		r := Rect{Llx: x, Lly: y, Urx: x, Ury: y}
+		if i == 0 {
+			bbox = r
+			continue
+		}
+		bbox = bbox.union(r)
+	}
+
+	return bbox
+}
@@ -0,0 +1,744 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// indexVersion is the version of the index format. Indexes having a
+// different version must be rebuilt.
+const indexVersion = 1
+
+const (
+	// indexFileName is the name of the file containing the documents and
+	// the postings of the index.
+	indexFileName = "index.gob.gz"
+
+	// indexTextDir is the name of the directory containing the page texts
+	// of the indexed documents, used for generating snippets.
+	indexTextDir = "text"
+)
+
+// IndexBuildResult contains the outcome of building or updating an index.
+type IndexBuildResult struct {
+	// Added, Updated, Removed and Unchanged contain the paths of the files
+	// which were added to, updated in, removed from or left unchanged in
+	// the index.
+	Added     []string
+	Updated   []string
+	Removed   []string
+	Unchanged []string
+
+	// Failed contains the files which could not be indexed, along with the
+	// encountered errors.
+	Failed map[string]error
+}
+
+// IndexHit represents a page of an indexed file matching a query.
+type IndexHit struct {
+	// File is the path of the matching file.
+	File string `json:"file"`
+
+	// Page is the number of the matching page.
+	Page int `json:"page"`
+
+	// Score is the relevance of the page. Higher scores are more relevant.
+	Score float64 `json:"score"`
+
+	// Occurrences is the number of occurrences of the query terms on the page.
+	Occurrences int `json:"occurrences"`
+
+	// Snippet contains the first occurrence of the query, along with its
+	// surrounding text.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// index represents an inverted index of the text of a PDF corpus. The index
+// contains the positions of the terms on each page of the indexed documents.
+type index struct {
+	Version int
+	NextID  int
+	Docs    map[int]*indexDoc
+	Terms   map[string][]*indexPosting
+}
+
+// indexDoc represents an indexed PDF file.
+type indexDoc struct {
+	Path    string
+	ModTime time.Time
+	Size    int64
+	Hash    string
+
+	// PageLengths contains the number of terms on each page of the file.
+	PageLengths []int
+}
+
+// indexPosting contains the positions of a term on a page of a document.
+type indexPosting struct {
+	Doc       int
+	Page      int
+	Positions []int
+}
+
+// indexPage identifies a page of an indexed document.
+type indexPage struct {
+	doc  int
+	page int
+}
+
+// BuildIndex creates or updates the index stored in the directory specified
+// by the indexDir parameter, using the text of the PDF files specified by the
+// inputPaths parameter. The index is updated incrementally: files having the
+// same modification time, size and content hash as when they were indexed are
+// skipped, while indexed files which no longer exist are removed from the
+// index. The text of the files is extracted in parallel, using the number of
+// workers specified by the workers parameter. If the workers parameter is not
+// positive, the number of logical CPUs is used. A password can be passed in
+// for encrypted input files.
+// The files which cannot be indexed do not stop the process. Instead, the
+// encountered errors are reported in the returned result.
+func BuildIndex(indexDir string, inputPaths []string, password string, workers int) (*IndexBuildResult, error) {
+	idx, err := loadIndex(indexDir, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(indexDir, indexTextDir), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	res := &IndexBuildResult{Failed: map[string]error{}}
+	docIDs := map[string]int{}
+	for id, doc := range idx.Docs {
+		docIDs[doc.Path] = id
+	}
+
+	// Find the files which no longer exist.
+	removed := map[int]bool{}
+	for path, id := range docIDs {
+		if _, err := os.Stat(path); err != nil && errors.Is(err, os.ErrNotExist) {
+			if err := os.Remove(idx.textPath(indexDir, id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+
+			removed[id] = true
+			delete(docIDs, path)
+			res.Removed = append(res.Removed, path)
+		}
+	}
+
+	// Find the files which have to be indexed.
+	var pending []*indexDoc
+	seen := map[string]bool{}
+	for _, inputPath := range inputPaths {
+		doc, err := newIndexDoc(inputPath)
+		if err != nil {
+			res.Failed[inputPath] = err
+			continue
+		}
+		if seen[doc.Path] {
+			continue
+		}
+		seen[doc.Path] = true
+
+		if id, ok := docIDs[doc.Path]; ok {
+			prev := idx.Docs[id]
+			if prev.ModTime.Equal(doc.ModTime) && prev.Size == doc.Size {
+				res.Unchanged = append(res.Unchanged, doc.Path)
+				continue
+			}
+			if err := doc.computeHash(); err != nil {
+				res.Failed[inputPath] = err
+				continue
+			}
+			if prev.Hash == doc.Hash {
+				prev.ModTime, prev.Size = doc.ModTime, doc.Size
+				res.Unchanged = append(res.Unchanged, doc.Path)
+				continue
+			}
+		} else if err := doc.computeHash(); err != nil {
+			res.Failed[inputPath] = err
+			continue
+		}
+
+		pending = append(pending, doc)
+	}
+
+	// Extract the text of the pending files.
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	pageTexts := make([][]string, len(pending))
+	errs := make([]error, len(pending))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				pages, err := ExtractTextPages(pending[i].Path, password, nil)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+
+				texts := make([]string, len(pages))
+				for j, page := range pages {
+					texts[j] = page.Text
+				}
+				pageTexts[i] = texts
+			}
+		}()
+	}
+
+	for i := range pending {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	// Remove the outdated postings.
+	for i, doc := range pending {
+		if id, ok := docIDs[doc.Path]; ok && errs[i] == nil {
+			removed[id] = true
+		}
+	}
+	idx.removeDocs(removed)
+
+	// Add the text of the pending files to the index.
+	for i, doc := range pending {
+		if errs[i] != nil {
+			res.Failed[doc.Path] = errs[i]
+			continue
+		}
+
+		id, ok := docIDs[doc.Path]
+		if ok {
+			res.Updated = append(res.Updated, doc.Path)
+		} else {
+			id = idx.NextID
+			idx.NextID++
+			res.Added = append(res.Added, doc.Path)
+		}
+
+		if err := writeIndexText(idx.textPath(indexDir, id), pageTexts[i]); err != nil {
+			return nil, err
+		}
+		idx.addDoc(id, doc, pageTexts[i])
+	}
+
+	// Save index.
+	if err := idx.save(indexDir); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// SearchIndex searches the index stored in the directory specified by the
+// indexDir parameter and returns the pages matching the provided query,
+// ordered by relevance. The relevance of the pages is computed using the
+// BM25 ranking function.
+// The query consists of terms, which are matched case insensitively, and of
+// phrases enclosed in double quotes, which are matched only if their terms
+// appear consecutively. A page matches the query if it contains all the
+// terms and phrases of the query.
+// The number of returned hits can be limited using the limit parameter. If
+// the limit parameter is not positive, all the hits are returned. The
+// context parameter specifies the number of characters of surrounding text
+// included in the snippets of the hits. If 0, no snippets are generated.
+func SearchIndex(indexDir, query string, limit, context int) ([]*IndexHit, error) {
+	idx, err := loadIndex(indexDir, false)
+	if err != nil {
+		return nil, err
+	}
+
+	clauses := parseIndexQuery(query)
+	if len(clauses) == 0 {
+		return nil, errors.New("the query does not contain any terms")
+	}
+
+	// Find the pages matching all the clauses of the query. The positions
+	// of the first clause are kept, in order to generate the snippets.
+	var matches map[indexPage][]int
+	for _, clause := range clauses {
+		clauseMatches := idx.phrasePositions(clause)
+		if matches == nil {
+			matches = clauseMatches
+			continue
+		}
+
+		for key := range matches {
+			if _, ok := clauseMatches[key]; !ok {
+				delete(matches, key)
+			}
+		}
+	}
+
+	// Compute the number of terms of the indexed pages.
+	var numPages, numTerms int
+	for _, doc := range idx.Docs {
+		numPages += len(doc.PageLengths)
+		for _, length := range doc.PageLengths {
+			numTerms += length
+		}
+	}
+	avgLength := 1.0
+	if numPages > 0 && numTerms > 0 {
+		avgLength = float64(numTerms) / float64(numPages)
+	}
+
+	// Collect the distinct terms of the query.
+	var terms []string
+	seen := map[string]bool{}
+	for _, clause := range clauses {
+		for _, term := range clause {
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+
+	// Score the matching pages.
+	const k1, b = 1.2, 0.75
+
+	hits := make([]*IndexHit, 0, len(matches))
+	positions := map[*IndexHit][]int{}
+	for key, clausePositions := range matches {
+		doc := idx.Docs[key.doc]
+		length := float64(doc.PageLengths[key.page-1])
+
+		hit := &IndexHit{File: doc.Path, Page: key.page}
+		for _, term := range terms {
+			postings := idx.Terms[term]
+			i := sort.Search(len(postings), func(i int) bool {
+				p := postings[i]
+				return p.Doc > key.doc || (p.Doc == key.doc && p.Page >= key.page)
+			})
+			if i == len(postings) || postings[i].Doc != key.doc || postings[i].Page != key.page {
+				continue
+			}
+
+			tf := float64(len(postings[i].Positions))
+			idf := math.Log(1 + (float64(numPages)-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+			hit.Score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*length/avgLength))
+			hit.Occurrences += len(postings[i].Positions)
+		}
+
+		hits = append(hits, hit)
+		positions[hit] = clausePositions
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].File != hits[j].File {
+			return hits[i].File < hits[j].File
+		}
+		return hits[i].Page < hits[j].Page
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	// Generate snippets.
+	if context <= 0 {
+		return hits, nil
+	}
+
+	docIDs := map[string]int{}
+	for id, doc := range idx.Docs {
+		docIDs[doc.Path] = id
+	}
+
+	texts := map[int][]string{}
+	for _, hit := range hits {
+		id := docIDs[hit.File]
+		pageTexts, ok := texts[id]
+		if !ok {
+			if pageTexts, err = readIndexText(idx.textPath(indexDir, id)); err != nil {
+				return nil, err
+			}
+			texts[id] = pageTexts
+		}
+		if hit.Page > len(pageTexts) || len(positions[hit]) == 0 {
+			continue
+		}
+
+		text := pageTexts[hit.Page-1]
+		tokens := tokenize(text)
+
+		start := positions[hit][0]
+		end := start + len(clauses[0]) - 1
+		if end >= len(tokens) {
+			continue
+		}
+
+		offset := tokens[start].offset
+		length := tokens[end].offset + tokens[end].length - offset
+		hit.Snippet = snippet(text, offset, length, context)
+	}
+
+	return hits, nil
+}
+
+// newIndexDoc returns a new document for the PDF file specified by the
+// inputPath parameter, containing its absolute path, size and modification
+// time.
+func newIndexDoc(inputPath string) (*indexDoc, error) {
+	path, err := filepath.Abs(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &indexDoc{Path: path, ModTime: info.ModTime(), Size: info.Size()}, nil
+}
+
+// computeHash computes the SHA-256 hash of the contents of the document.
+func (doc *indexDoc) computeHash() error {
+	f, err := os.Open(doc.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	doc.Hash = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// loadIndex loads the index stored in the specified directory. If the index
+// does not exist and the create parameter is true, an empty index is
+// returned.
+func loadIndex(indexDir string, create bool) (*index, error) {
+	idx := &index{
+		Version: indexVersion,
+		Docs:    map[int]*indexDoc{},
+		Terms:   map[string][]*indexPosting{},
+	}
+
+	f, err := os.Open(filepath.Join(indexDir, indexFileName))
+	if err != nil {
+		if create && errors.Is(err, os.ErrNotExist) {
+			return idx, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	if err := gob.NewDecoder(gr).Decode(idx); err != nil {
+		return nil, fmt.Errorf("could not read index: %w", err)
+	}
+	if idx.Version != indexVersion {
+		return nil, fmt.Errorf("unsupported index version %d, the index must be rebuilt", idx.Version)
+	}
+	if idx.Docs == nil {
+		idx.Docs = map[int]*indexDoc{}
+	}
+	if idx.Terms == nil {
+		idx.Terms = map[string][]*indexPosting{}
+	}
+
+	return idx, nil
+}
+
+// save saves the index in the specified directory. The index is written to
+// a temporary file first, in order to leave the existing index intact if the
+// operation fails.
+func (idx *index) save(indexDir string) error {
+	f, err := os.CreateTemp(indexDir, indexFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+
+	gw := gzip.NewWriter(f)
+	if err := gob.NewEncoder(gw).Encode(idx); err != nil {
+		f.Close()
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, filepath.Join(indexDir, indexFileName))
+}
+
+// textPath returns the path of the file containing the page texts of the
+// specified document.
+func (idx *index) textPath(indexDir string, id int) string {
+	return filepath.Join(indexDir, indexTextDir, fmt.Sprintf("%d.json.gz", id))
+}
+
+// addDoc adds the terms of the provided page texts to the index.
+func (idx *index) addDoc(id int, doc *indexDoc, pageTexts []string) {
+	docTerms := map[string]bool{}
+	doc.PageLengths = make([]int, len(pageTexts))
+	for i, text := range pageTexts {
+		pagePostings := map[string]*indexPosting{}
+
+		tokens := tokenize(text)
+		for pos, token := range tokens {
+			posting, ok := pagePostings[token.text]
+			if !ok {
+				posting = &indexPosting{Doc: id, Page: i + 1}
+				pagePostings[token.text] = posting
+				idx.Terms[token.text] = append(idx.Terms[token.text], posting)
+				docTerms[token.text] = true
+			}
+			posting.Positions = append(posting.Positions, pos)
+		}
+
+		doc.PageLengths[i] = len(tokens)
+	}
+
+	idx.Docs[id] = doc
+
+	// Keep the postings sorted by document and page. Updated documents keep
+	// their IDs, so their postings might have to be moved.
+	for term := range docTerms {
+		postings := idx.Terms[term]
+		less := func(i, j int) bool {
+			if postings[i].Doc != postings[j].Doc {
+				return postings[i].Doc < postings[j].Doc
+			}
+			return postings[i].Page < postings[j].Page
+		}
+		if !sort.SliceIsSorted(postings, less) {
+			sort.SliceStable(postings, less)
+		}
+	}
+}
+
+// removeDocs removes the specified documents and their postings from the
+// index.
+func (idx *index) removeDocs(ids map[int]bool) {
+	if len(ids) == 0 {
+		return
+	}
+
+	for term, postings := range idx.Terms {
+		filtered := postings[:0]
+		for _, posting := range postings {
+			if !ids[posting.Doc] {
+				filtered = append(filtered, posting)
+			}
+		}
+
+		if len(filtered) == 0 {
+			delete(idx.Terms, term)
+		} else {
+			idx.Terms[term] = filtered
+		}
+	}
+
+	for id := range ids {
+		delete(idx.Docs, id)
+	}
+}
+
+// phrasePositions returns the positions of the specified phrase on each
+// page containing it, keyed by document ID and page number.
+func (idx *index) phrasePositions(phrase []string) map[indexPage][]int {
+	matches := map[indexPage][]int{}
+	for _, posting := range idx.Terms[phrase[0]] {
+		matches[indexPage{posting.Doc, posting.Page}] = posting.Positions
+	}
+
+	for i, term := range phrase[1:] {
+		termPositions := map[indexPage]map[int]bool{}
+		for _, posting := range idx.Terms[term] {
+			key := indexPage{posting.Doc, posting.Page}
+			if _, ok := matches[key]; !ok {
+				continue
+			}
+
+			positions := map[int]bool{}
+			for _, pos := range posting.Positions {
+				positions[pos] = true
+			}
+			termPositions[key] = positions
+		}
+
+		for key, starts := range matches {
+			var filtered []int
+			for _, start := range starts {
+				if termPositions[key][start+i+1] {
+					filtered = append(filtered, start)
+				}
+			}
+
+			if len(filtered) == 0 {
+				delete(matches, key)
+			} else {
+				matches[key] = filtered
+			}
+		}
+	}
+
+	return matches
+}
+
+// parseIndexQuery returns the clauses of the provided query. Each phrase
+// enclosed in double quotes and each word outside of quotes is a clause,
+// containing the terms of the phrase or of the word.
+func parseIndexQuery(query string) [][]string {
+	var clauses [][]string
+	addClause := func(text string) {
+		var terms []string
+		for _, token := range tokenize(text) {
+			terms = append(terms, token.text)
+		}
+		if len(terms) > 0 {
+			clauses = append(clauses, terms)
+		}
+	}
+
+	for i, part := range strings.Split(query, `"`) {
+		// The odd parts are enclosed in double quotes.
+		if i%2 == 1 {
+			addClause(part)
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			addClause(word)
+		}
+	}
+
+	return clauses
+}
+
+// indexToken represents a term found in text.
+type indexToken struct {
+	text string
+
+	// offset and length represent the position of the term in the text,
+	// in bytes.
+	offset int
+	length int
+}
+
+// tokenize splits the provided text into lowercase terms, consisting of
+// letters and digits.
+func tokenize(text string) []indexToken {
+	var tokens []indexToken
+
+	start := -1
+	for i := 0; i <= len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if i < len(text) && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if start < 0 {
+				start = i
+			}
+			i += size
+			continue
+		}
+
+		if start >= 0 {
+			tokens = append(tokens, indexToken{
+				text:   strings.ToLower(text[start:i]),
+				offset: start,
+				length: i - start,
+			})
+			start = -1
+		}
+		if i == len(text) {
+			break
+		}
+		i += size
+	}
+
+	return tokens
+}
+
+// writeIndexText saves the provided page texts to the specified file.
+func writeIndexText(path string, pageTexts []string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(f)
+	if err := json.NewEncoder(gw).Encode(pageTexts); err != nil {
+		f.Close()
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// readIndexText reads the page texts saved in the specified file.
+func readIndexText(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	var pageTexts []string
+	if err := json.NewDecoder(gr).Decode(&pageTexts); err != nil {
+		return nil, err
+	}
+
+	return pageTexts, nil
+}
@@ -0,0 +1,680 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// MarkdownOpts represents the options used for converting PDF files to
+// Markdown.
+type MarkdownOpts struct {
+	// IgnoreStructure specifies if the structure tree of tagged PDF files
+	// is ignored. If true, the structure of the document is always inferred
+	// from the layout of the text.
+	IgnoreStructure bool
+}
+
+// ExtractMarkdown converts the text content of the PDF file specified by the
+// inputPath parameter to Markdown. A password can be specified for encrypted
+// PDF files. Also, a list of pages to convert can be passed in. If the pages
+// parameter is nil or an empty slice, all the pages of the file are converted.
+// For tagged PDF files, the headings, paragraphs, lists and tables are read
+// from the structure tree of the file. Otherwise, headings are inferred from
+// the document outline and from the size and weight of the fonts, while
+// paragraphs and lists are rebuilt from the geometry of the text lines.
+func ExtractMarkdown(inputPath, password string, pages []int, opts *MarkdownOpts) (string, error) {
+	if opts == nil {
+		opts = &MarkdownOpts{}
+	}
+
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return "", err
+	}
+
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
+
+	// Use the structure tree of tagged files.
+	if !opts.IgnoreStructure {
+		if root, ok := parseStructTree(r); ok {
+			md, err := structMarkdown(r, root, pages)
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(md) != "" {
+				return md, nil
+			}
+		}
+	}
+
+	// Infer the structure of the document from the text layout.
+	var pageTexts []*PageText
+	for _, numPage := range pages {
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return "", err
+		}
+
+		pageText, err := newPageText(page, numPage)
+		if err != nil {
+			return "", err
+		}
+		pageTexts = append(pageTexts, pageText)
+	}
+
+	outline, err := r.GetOutlines()
+	if err != nil {
+		outline = nil
+	}
+
+	return layoutMarkdown(pageTexts, outline), nil
+}
+
+// markdownWriter assembles Markdown blocks.
+type markdownWriter struct {
+	blocks []string
+}
+
+func (mw *markdownWriter) heading(level int, text string) {
+	if text = normalizeSpaces(text); text == "" {
+		return
+	}
+	if level < 1 {
+		level = 1
+	}
+	if level > 6 {
+		level = 6
+	}
+
+	mw.blocks = append(mw.blocks, strings.Repeat("#", level)+" "+text)
+}
+
+func (mw *markdownWriter) paragraph(text string) {
+	if text = normalizeSpaces(text); text != "" {
+		mw.blocks = append(mw.blocks, text)
+	}
+}
+
+func (mw *markdownWriter) list(items []string, ordered bool) {
+	var lines []string
+	for i, item := range items {
+		if item = normalizeSpaces(item); item == "" {
+			continue
+		}
+
+		marker := "-"
+		if ordered {
+			marker = strconv.Itoa(i+1) + "."
+		}
+		lines = append(lines, marker+" "+item)
+	}
+
+	if len(lines) > 0 {
+		mw.blocks = append(mw.blocks, strings.Join(lines, "\n"))
+	}
+}
+
+func (mw *markdownWriter) table(rows [][]string) {
+	var cols int
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return
+	}
+
+	formatRow := func(row []string) string {
+		cells := make([]string, cols)
+		for i := range cells {
+			if i < len(row) {
+				cells[i] = strings.ReplaceAll(normalizeSpaces(row[i]), "|", `\|`)
+			}
+		}
+		return "| " + strings.Join(cells, " | ") + " |"
+	}
+
+	lines := []string{formatRow(rows[0])}
+	lines = append(lines, "|"+strings.Repeat(" --- |", cols))
+	for _, row := range rows[1:] {
+		lines = append(lines, formatRow(row))
+	}
+
+	mw.blocks = append(mw.blocks, strings.Join(lines, "\n"))
+}
+
+func (mw *markdownWriter) String() string {
+	if len(mw.blocks) == 0 {
+		return ""
+	}
+
+	return strings.Join(mw.blocks, "\n\n") + "\n"
+}
+
+// structMarkdown converts the content of the specified pages to Markdown,
+// using the structure tree of the file.
+func structMarkdown(r *unipdf.PdfReader, root *structElement, pages []int) (string, error) {
+	// Extract the text of the marked-content sequences of each page.
+	texts := map[int64]map[int]string{}
+	for _, numPage := range pages {
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return "", err
+		}
+
+		pageObj := page.GetPageAsIndirectObject()
+		if pageObj == nil {
+			continue
+		}
+
+		if texts[pageObj.ObjectNumber], err = markedContentText(page); err != nil {
+			return "", err
+		}
+	}
+
+	sr := &structRenderer{texts: texts}
+	sr.render(root)
+
+	return sr.mw.String(), nil
+}
+
+// structRenderer renders structure elements as Markdown.
+type structRenderer struct {
+	mw    markdownWriter
+	texts map[int64]map[int]string
+}
+
+var headingRoleRegexp = regexp.MustCompile(`^H([1-6])?$`)
+
+func (sr *structRenderer) render(elem *structElement) {
+	switch role := elem.role; {
+	case role == "Artifact":
+		// Artifacts are not part of the document content.
+	case headingRoleRegexp.MatchString(role):
+		level := 1
+		if match := headingRoleRegexp.FindStringSubmatch(role); match[1] != "" {
+			level = int(match[1][0] - '0')
+		}
+		sr.mw.heading(level, sr.text(elem))
+	case role == "P" || role == "Caption" || role == "Note" || role == "Quote" ||
+		role == "Code" || role == "TOCI":
+		sr.mw.paragraph(sr.text(elem))
+	case role == "BlockQuote":
+		if text := normalizeSpaces(sr.text(elem)); text != "" {
+			sr.mw.blocks = append(sr.mw.blocks, "> "+text)
+		}
+	case role == "L":
+		sr.renderList(elem)
+	case role == "Table":
+		sr.renderTable(elem)
+	case role == "Figure" || role == "Formula":
+		if elem.actualText != "" {
+			sr.mw.paragraph(elem.actualText)
+		}
+	default:
+		// Render grouping elements recursively. Inline elements and text
+		// placed directly under grouping elements are rendered as paragraphs.
+		var inline []string
+		flush := func() {
+			sr.mw.paragraph(strings.Join(inline, " "))
+			inline = nil
+		}
+
+		for _, kid := range elem.kids {
+			if kid.elem == nil {
+				inline = append(inline, sr.mcidText(kid))
+				continue
+			}
+			if isInlineRole(kid.elem.role) {
+				inline = append(inline, sr.text(kid.elem))
+				continue
+			}
+
+			flush()
+			sr.render(kid.elem)
+		}
+		flush()
+	}
+}
+
+func (sr *structRenderer) renderList(elem *structElement) {
+	var items []string
+	var ordered bool
+
+	for _, kid := range elem.kids {
+		if kid.elem == nil {
+			continue
+		}
+		if kid.elem.role == "L" {
+			sr.mw.list(items, ordered)
+			items = nil
+			sr.renderList(kid.elem)
+			continue
+		}
+
+		var label, body []string
+		for _, itemKid := range kid.elem.kids {
+			switch {
+			case itemKid.elem == nil:
+				body = append(body, sr.mcidText(itemKid))
+			case itemKid.elem.role == "Lbl":
+				label = append(label, sr.text(itemKid.elem))
+			default:
+				body = append(body, sr.text(itemKid.elem))
+			}
+		}
+
+		lbl := strings.TrimSpace(strings.Join(label, ""))
+		if len(items) == 0 && lbl != "" && unicode.IsDigit([]rune(lbl)[0]) {
+			ordered = true
+		}
+		items = append(items, strings.Join(body, " "))
+	}
+
+	sr.mw.list(items, ordered)
+}
+
+func (sr *structRenderer) renderTable(elem *structElement) {
+	var rows [][]string
+
+	var collect func(elem *structElement)
+	collect = func(elem *structElement) {
+		for _, kid := range elem.kids {
+			if kid.elem == nil {
+				continue
+			}
+
+			switch kid.elem.role {
+			case "TR":
+				var row []string
+				for _, cell := range kid.elem.kids {
+					if cell.elem != nil {
+						row = append(row, sr.text(cell.elem))
+					}
+				}
+				rows = append(rows, row)
+			case "THead", "TBody", "TFoot":
+				collect(kid.elem)
+			}
+		}
+	}
+	collect(elem)
+
+	if len(rows) > 0 {
+		sr.mw.table(rows)
+	}
+}
+
+// text returns the text of the specified element and of its descendants.
+func (sr *structRenderer) text(elem *structElement) string {
+	if elem.actualText != "" {
+		return elem.actualText
+	}
+
+	var parts []string
+	for _, kid := range elem.kids {
+		var text string
+		if kid.elem == nil {
+			text = sr.mcidText(kid)
+		} else if kid.elem.role != "Artifact" {
+			text = sr.text(kid.elem)
+		}
+
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	return joinText(parts)
+}
+
+// mcidText returns the text of the specified marked-content sequence.
+func (sr *structRenderer) mcidText(kid structKid) string {
+	return sr.texts[kid.page][kid.mcid]
+}
+
+// isInlineRole returns true if the specified structure type represents
+// an inline element.
+func isInlineRole(role string) bool {
+	switch role {
+	case "Span", "Link", "Quote", "Note", "Reference", "BibEntry", "Code",
+		"Lbl", "Annot", "Ruby", "Warichu", "Em", "Strong", "Sub":
+		return true
+	}
+
+	return false
+}
+
+// layoutMarkdown converts the provided page texts to Markdown, inferring
+// the structure of the document from the layout of the text.
+func layoutMarkdown(pageTexts []*PageText, outline *unipdf.Outline) string {
+	// Collect outline titles.
+	outlineLevels := map[string]int{}
+	if outline != nil {
+		var collect func(items []*unipdf.OutlineItem, level int)
+		collect = func(items []*unipdf.OutlineItem, level int) {
+			for _, item := range items {
+				title := strings.ToLower(normalizeSpaces(item.Title))
+				if _, ok := outlineLevels[title]; !ok && title != "" {
+					outlineLevels[title] = level
+				}
+				collect(item.Entries, level+1)
+			}
+		}
+		collect(outline.Entries, 1)
+	}
+
+	// Compute the body font size and the heading font sizes.
+	sizeCounts := map[float64]int{}
+	for _, pageText := range pageTexts {
+		for _, line := range pageText.Lines {
+			size, _ := lineStyle(line)
+			sizeCounts[size] += utf8.RuneCountInString(line.Text)
+		}
+	}
+
+	var bodySize float64
+	var bodyCount int
+	for size, count := range sizeCounts {
+		if count > bodyCount || (count == bodyCount && size < bodySize) {
+			bodySize, bodyCount = size, count
+		}
+	}
+
+	var headingSizes []float64
+	for size := range sizeCounts {
+		if size >= bodySize*1.15 {
+			headingSizes = append(headingSizes, size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(headingSizes)))
+
+	headingLevel := func(line TextLine) int {
+		text := strings.ToLower(normalizeSpaces(line.Text))
+		if level, ok := outlineLevels[text]; ok {
+			return level
+		}
+
+		size, bold := lineStyle(line)
+		for i, headingSize := range headingSizes {
+			if size == headingSize {
+				return int(math.Min(float64(i+1), 4))
+			}
+		}
+
+		if bold && size >= bodySize && utf8.RuneCountInString(text) <= 80 &&
+			!strings.HasSuffix(text, ".") {
+			return int(math.Min(float64(len(headingSizes)+1), 4))
+		}
+
+		return 0
+	}
+
+	// Group lines into blocks.
+	var mw markdownWriter
+	for _, pageText := range pageTexts {
+		tables := append([]Table(nil), pageText.Tables...)
+
+		var block []TextLine
+		var blockLevel int
+		var listItems []string
+		var ordered bool
+
+		flushBlock := func() {
+			if len(block) == 0 {
+				return
+			}
+
+			var lines []string
+			for _, line := range block {
+				lines = append(lines, line.Text)
+			}
+
+			if blockLevel > 0 {
+				mw.heading(blockLevel, strings.Join(lines, " "))
+			} else {
+				mw.paragraph(joinLines(lines))
+			}
+			block = nil
+		}
+		flushList := func() {
+			mw.list(listItems, ordered)
+			listItems = nil
+		}
+		tableAbove := func(y float64) bool {
+			for _, table := range tables {
+				if table.BBox.Lly >= y {
+					return true
+				}
+			}
+			return false
+		}
+		flushTables := func(y float64, all bool) {
+			remaining := tables[:0]
+			for _, table := range tables {
+				if all || table.BBox.Lly >= y {
+					mw.table(table.Rows)
+				} else {
+					remaining = append(remaining, table)
+				}
+			}
+			tables = remaining
+		}
+
+		var prev *TextLine
+		for i := range pageText.Lines {
+			line := pageText.Lines[i]
+
+			// Skip the lines which are part of a table.
+			cx := (line.BBox.Llx + line.BBox.Urx) / 2
+			cy := (line.BBox.Lly + line.BBox.Ury) / 2
+			if inTable(pageText.Tables, cx, cy) {
+				continue
+			}
+
+			// Add the tables placed above the current line.
+			if tableAbove(cy) {
+				flushBlock()
+				flushList()
+				flushTables(cy, false)
+			}
+
+			level := headingLevel(line)
+
+			// Handle list items.
+			if text, isOrdered, ok := listItem(line.Text); ok && level == 0 {
+				flushBlock()
+				if len(listItems) == 0 {
+					ordered = isOrdered
+				}
+				listItems = append(listItems, text)
+				prev = &pageText.Lines[i]
+				continue
+			}
+
+			// Handle list item continuation lines.
+			if len(listItems) > 0 && level == 0 && prev != nil && !lineBreak(*prev, line) &&
+				line.BBox.Llx > prev.BBox.Llx {
+				listItems[len(listItems)-1] = joinLines([]string{listItems[len(listItems)-1], line.Text})
+				prev = &pageText.Lines[i]
+				continue
+			}
+			flushList()
+
+			// Start a new block if needed.
+			if len(block) > 0 && (level != blockLevel || lineBreak(block[len(block)-1], line)) {
+				flushBlock()
+			}
+
+			block = append(block, line)
+			blockLevel = level
+			prev = &pageText.Lines[i]
+		}
+
+		flushBlock()
+		flushList()
+		flushTables(0, true)
+	}
+
+	return mw.String()
+}
+
+// lineStyle returns the dominant font size of the provided line, rounded
+// to half points, and whether the line is bold.
+func lineStyle(line TextLine) (float64, bool) {
+	sizes := map[float64]int{}
+	var boldCount, count int
+
+	for _, mark := range line.Marks {
+		if mark.Meta || strings.TrimSpace(mark.Text) == "" {
+			continue
+		}
+
+		sizes[math.Round(mark.FontSize*2)/2]++
+		count++
+
+		font := strings.ToLower(mark.Font)
+		if strings.Contains(font, "bold") || strings.Contains(font, "black") ||
+			strings.Contains(font, "heavy") {
+			boldCount++
+		}
+	}
+
+	var size float64
+	var sizeCount int
+	for s, c := range sizes {
+		if c > sizeCount || (c == sizeCount && s > size) {
+			size, sizeCount = s, c
+		}
+	}
+
+	return size, count > 0 && boldCount*2 > count
+}
+
+// lineBreak returns true if the provided lines are not part of the same
+// paragraph, based on their vertical distance and font size.
+func lineBreak(prev, line TextLine) bool {
+	height := math.Max(prev.BBox.Height(), line.BBox.Height())
+	if height <= 0 {
+		return false
+	}
+
+	// New column or page region.
+	if line.BBox.Lly > prev.BBox.Ury {
+		return true
+	}
+
+	// Large vertical gap.
+	if prev.BBox.Lly-line.BBox.Ury > height*0.8 {
+		return true
+	}
+
+	prevSize, _ := lineStyle(prev)
+	size, _ := lineStyle(line)
+	return math.Abs(prevSize-size) > 1
+}
+
+var (
+	bulletRegexp   = regexp.MustCompile(`^\s*([•◦▪▫‣●○■□\-–—*·])\s+(.*)$`)
+	numberedRegexp = regexp.MustCompile(`^\s*(\d{1,3}[.)]|[a-zA-Z][.)])\s+(.*)$`)
+)
+
+// listItem checks if the provided text starts with a list marker. The
+// function returns the text of the item, without the marker, and whether
+// the item is part of an ordered list.
+func listItem(text string) (string, bool, bool) {
+	if match := bulletRegexp.FindStringSubmatch(text); match != nil {
+		return match[2], false, true
+	}
+	if match := numberedRegexp.FindStringSubmatch(text); match != nil {
+		return match[2], true, true
+	}
+
+	return "", false, false
+}
+
+// inTable returns true if the specified point is inside one of the
+// provided tables.
+func inTable(tables []Table, x, y float64) bool {
+	for _, table := range tables {
+		if x >= table.BBox.Llx && x <= table.BBox.Urx &&
+			y >= table.BBox.Lly && y <= table.BBox.Ury {
+			return true
+		}
+	}
+
+	return false
+}
+
+// joinLines joins the lines of a paragraph, removing the hyphens of the
+// words split across lines.
+func joinLines(lines []string) string {
+	var sb strings.Builder
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		str := sb.String()
+		switch {
+		case str == "":
+		case strings.HasSuffix(str, "-") && len(str) > 1 &&
+			unicode.IsLetter([]rune(str)[len([]rune(str))-2]) &&
+			unicode.IsLower([]rune(line)[0]):
+			sb.Reset()
+			sb.WriteString(strings.TrimSuffix(str, "-"))
+		default:
+			sb.WriteString(" ")
+		}
+
+		sb.WriteString(line)
+	}
+
+	return sb.String()
+}
+
+// joinText joins the provided text fragments, adding spaces between them
+// when needed.
+func joinText(parts []string) string {
+	var sb strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			str := sb.String()
+			last, _ := utf8.DecodeLastRuneInString(str)
+			first, _ := utf8.DecodeRuneInString(part)
+			if !unicode.IsSpace(last) && !unicode.IsSpace(first) {
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString(part)
+	}
+
+	return sb.String()
+}
+
+// normalizeSpaces collapses consecutive whitespace characters into single
+// spaces and trims the provided text.
+func normalizeSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,220 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	unicontent "github.com/unidoc/unipdf/v4/contentstream"
+	unicore "github.com/unidoc/unipdf/v4/core"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// OverlayOpts represents the options used for overlaying the pages of a
+// template PDF file on the pages of another PDF file.
+type OverlayOpts struct {
+	// TemplatePath specifies the path of the template PDF file.
+	TemplatePath string
+
+	// TemplatePassword specifies the password of the template file.
+	TemplatePassword string
+
+	// TemplatePages specifies the template pages applied to the pages of
+	// the input file, in order. If the Cycle field is false, the last
+	// template page is used for all the remaining pages of the input file.
+	// If not specified, the first page of the template file is used.
+	TemplatePages []int
+
+	// FirstPage specifies the template page applied to the first page of
+	// the input file, if the first page is selected. The rest of the pages
+	// use the template pages specified by the TemplatePages field. If 0,
+	// the first page of the input file is handled like all the other pages.
+	FirstPage int
+
+	// Cycle specifies if the template pages are repeated cyclically over
+	// the pages of the input file.
+	Cycle bool
+
+	// Under specifies if the template pages are drawn under the page
+	// contents (underlay). Otherwise, they are drawn over them (overlay).
+	Under bool
+}
+
+// Overlay draws the pages of the template file specified by the opts
+// parameter under or over the pages of the PDF file specified by the
+// inputPath parameter. The template pages are imported as form XObjects and
+// are scaled to fit the pages of the input file, if their sizes differ.
+// A password can be passed in for encrypted input files. The resulting file
+// is saved at the location specified by the outputPath parameter.
+// Also, a list of pages to apply the template to can be passed in. Every page
+// that is not included in the pages slice is left intact.
+// If the pages parameter is nil or an empty slice, the template is applied
+// to all the pages of the input file.
+func Overlay(inputPath, outputPath, password string, pages []int, opts *OverlayOpts) error {
+	if opts == nil || opts.TemplatePath == "" {
+		return errors.New("must specify the template file")
+	}
+
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return err
+	}
+
+	// Read template file.
+	tr, templateCount, _, _, err := readPDF(opts.TemplatePath, opts.TemplatePassword)
+	if err != nil {
+		return err
+	}
+
+	templatePages := opts.TemplatePages
+	if len(templatePages) == 0 {
+		templatePages = []int{1}
+	}
+
+	for _, numPage := range templatePages {
+		if numPage < 1 || numPage > templateCount {
+			return fmt.Errorf("template page %d does not exist", numPage)
+		}
+	}
+	if opts.FirstPage < 0 || opts.FirstPage > templateCount {
+		return fmt.Errorf("template page %d does not exist", opts.FirstPage)
+	}
+
+	// Apply template to the selected pages.
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
+
+	// The index of the template page applied to the next page, from the
+	// template pages specified by the options.
+	var index int
+
+	templates := map[int]*pageTemplate{}
+	for _, numPage := range pages {
+		if numPage < 1 || numPage > pageCount {
+			continue
+		}
+
+		// Choose template page.
+		numTemplate := opts.FirstPage
+		if opts.FirstPage == 0 || numPage != 1 {
+			numTemplate = templatePage(templatePages, index, opts.Cycle)
+			index++
+		}
+
+		// Load template page.
+		tpl, ok := templates[numTemplate]
+		if !ok {
+			tplPage, err := tr.GetPage(numTemplate)
+			if err != nil {
+				return err
+			}
+
+			if tpl, err = newPageTemplate(tplPage); err != nil {
+				return err
+			}
+			templates[numTemplate] = tpl
+		}
+
+		// Apply template page.
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return err
+		}
+
+		if err = tpl.apply(page, opts.Under); err != nil {
+			return err
+		}
+	}
+
+	// Copy input file contents.
+	w := unipdf.NewPdfWriter()
+	if err := readerToWriter(r, &w, nil); err != nil {
+		return err
+	}
+
+	// Write output file.
+	safe := inputPath == outputPath
+	return writePDF(outputPath, &w, safe)
+}
+
+// templatePage returns the template page used for the input page with the
+// specified index.
+func templatePage(templatePages []int, index int, cycle bool) int {
+	if cycle {
+		return templatePages[index%len(templatePages)]
+	}
+	if index >= len(templatePages) {
+		index = len(templatePages) - 1
+	}
+
+	return templatePages[index]
+}
+
+// pageTemplate represents a template page imported as a form XObject.
+type pageTemplate struct {
+	xform *unipdf.XObjectForm
+	box   *unipdf.PdfRectangle
+}
+
+func newPageTemplate(page *unipdf.PdfPage) (*pageTemplate, error) {
+	box, err := pageBox(page)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+
+	xform := unipdf.NewXObjectForm()
+	xform.Resources = page.Resources
+	xform.BBox = unicore.MakeArrayFromFloats([]float64{box.Llx, box.Lly, box.Urx, box.Ury})
+	if err := xform.SetContentStream([]byte(contents), unicore.NewFlateEncoder()); err != nil {
+		return nil, err
+	}
+
+	return &pageTemplate{xform: xform, box: box}, nil
+}
+
+// apply draws the template on the specified page.
+func (tpl *pageTemplate) apply(page *unipdf.PdfPage, under bool) error {
+	box, err := pageBox(page)
+	if err != nil {
+		return err
+	}
+	if page.Resources == nil {
+		page.Resources = unipdf.NewPdfPageResources()
+	}
+
+	// Add template to the page resources.
+	name := resourceName("XTemplate", page.Resources.HasXObjectByName)
+	if err := page.Resources.SetXObjectFormByName(name, tpl.xform); err != nil {
+		return err
+	}
+
+	// Scale the template to fit the page, if their sizes differ.
+	scale := 1.0
+	tplWidth, tplHeight := tpl.box.Width(), tpl.box.Height()
+	if tplWidth > 0 && tplHeight > 0 {
+		scale = math.Min(box.Width()/tplWidth, box.Height()/tplHeight)
+	}
+
+	tx := box.Llx + (box.Width()-scale*tplWidth)/2 - scale*tpl.box.Llx
+	ty := box.Lly + (box.Height()-scale*tplHeight)/2 - scale*tpl.box.Lly
+
+	cc := unicontent.NewContentCreator()
+	cc.Add_q().
+		Add_cm(scale, 0, 0, scale, tx, ty).
+		Add_Do(name).
+		Add_Q()
+
+	return addPageContents(page, cc.String(), under)
+}
@@ -0,0 +1,307 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// PIIDetector detects a type of personally identifiable information (PII),
+// such as email addresses or phone numbers, in text.
+type PIIDetector struct {
+	// Type is the type of the detected information (e.g. email).
+	Type string
+
+	// Pattern is the regular expression matching the information. Matches
+	// which are part of longer words or numbers are ignored.
+	Pattern string
+
+	// Validate, if specified, validates the matched text (e.g. using
+	// a checksum). Matches which are not valid are ignored.
+	Validate func(text string) bool
+}
+
+// piiDetectors contains the built-in PII detectors. When the matches of
+// multiple detectors overlap, the leftmost match is used. If the matches
+// start at the same offset, the detector listed first takes precedence.
+var piiDetectors = []*PIIDetector{
+	{
+		Type:    "email",
+		Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`,
+	},
+	{
+		Type:     "credit-card",
+		Pattern:  `\d(?:[ -]?\d){12,18}`,
+		Validate: isValidCardNumber,
+	},
+	{
+		Type:     "iban",
+		Pattern:  `[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?`,
+		Validate: isValidIBAN,
+	},
+	{
+		Type:     "ssn",
+		Pattern:  `\d{3}-\d{2}-\d{4}|\d{3} \d{2} \d{4}`,
+		Validate: isValidSSN,
+	},
+	{
+		Type:     "phone",
+		Pattern:  `(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)[ .-]?|\d{2,4}[ .-])\d{3,4}[ .-]?\d{3,4}`,
+		Validate: isValidPhoneNumber,
+	},
+}
+
+// PIIDetectorTypes returns the types of the built-in PII detectors.
+func PIIDetectorTypes() []string {
+	types := make([]string, 0, len(piiDetectors))
+	for _, detector := range piiDetectors {
+		types = append(types, detector.Type)
+	}
+
+	return types
+}
+
+// NewPIIDetectors returns the built-in PII detectors of the specified
+// types. If no types are specified, all the built-in detectors are
+// returned.
+func NewPIIDetectors(types []string) ([]*PIIDetector, error) {
+	if len(types) == 0 {
+		return append([]*PIIDetector(nil), piiDetectors...), nil
+	}
+
+	enabled := map[string]bool{}
+	for _, typ := range types {
+		enabled[typ] = true
+	}
+
+	var detectors []*PIIDetector
+	for _, detector := range piiDetectors {
+		if enabled[detector.Type] {
+			detectors = append(detectors, detector)
+			delete(enabled, detector.Type)
+		}
+	}
+	for typ := range enabled {
+		return nil, fmt.Errorf("unsupported PII type: %s", typ)
+	}
+
+	return detectors, nil
+}
+
+// replacer returns a text replacer matching the information detected by
+// the detector. The matches are replaced by the specified text.
+func (d *PIIDetector) replacer(replacement string) (*textReplacer, error) {
+	re, err := regexp.Compile(d.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s pattern: %v", d.Type, err)
+	}
+
+	return &textReplacer{
+		re:          re,
+		replacement: replacement,
+		accept: func(text string, start, end int) bool {
+			if !isWholeWord(text, start, end) || !isSeparateNumber(text, start, end) {
+				return false
+			}
+			return d.Validate == nil || d.Validate(text[start:end])
+		},
+	}, nil
+}
+
+// PIIFinding represents an occurrence of PII found on a page.
+type PIIFinding struct {
+	// Type is the type of the found information.
+	Type string `json:"type"`
+
+	// Text is the found text.
+	Text string `json:"text"`
+
+	// Offset and Length represent the position of the finding in the text
+	// of the page, in bytes.
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+
+	// BBoxes contains the bounding boxes of the finding, in PDF
+	// coordinates. Findings spanning multiple lines have a bounding box for
+	// each line.
+	BBoxes []Rect `json:"bboxes"`
+}
+
+// PIIScanResult contains the PII found on a page.
+type PIIScanResult struct {
+	// The number of the page.
+	Page int `json:"page"`
+
+	// The findings of the page, sorted by offset.
+	Findings []*PIIFinding `json:"findings"`
+}
+
+// ScanPII searches the PDF file specified by the inputPath parameter for
+// the information detected by the provided detectors. A password can be
+// passed in for encrypted input files.
+// Also, a list of pages to scan can be passed in. If the pages parameter is
+// nil or an empty slice, all the pages of the file are scanned.
+// The results only contain the pages on which information was found.
+func ScanPII(inputPath, password string, detectors []*PIIDetector, pages []int) ([]*PIIScanResult, error) {
+	replacers := make([]*textReplacer, 0, len(detectors))
+	for _, detector := range detectors {
+		replacer, err := detector.replacer("")
+		if err != nil {
+			return nil, err
+		}
+		replacers = append(replacers, replacer)
+	}
+
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
+
+	// Scan pages.
+	var results []*PIIScanResult
+	for _, numPage := range pages {
+		if numPage < 1 || numPage > pageCount {
+			continue
+		}
+
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return nil, err
+		}
+
+		pageText, err := newPageText(page, numPage)
+		if err != nil {
+			return nil, err
+		}
+
+		matches := findMatches(pageText.Text, replacers)
+		if len(matches) == 0 {
+			continue
+		}
+
+		result := &PIIScanResult{Page: numPage}
+		for _, match := range matches {
+			result.Findings = append(result.Findings, &PIIFinding{
+				Type:   detectors[match.replacer].Type,
+				Text:   pageText.Text[match.offset : match.offset+match.length],
+				Offset: match.offset,
+				Length: match.length,
+				BBoxes: pageText.rangeBBoxes(match.offset, match.length),
+			})
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// isSeparateNumber returns true if the specified range of the provided
+// text is not part of a longer number, in which groups of digits are
+// separated by spaces, dots or hyphens (e.g. a phone number contained by
+// an invalid card number).
+func isSeparateNumber(text string, start, end int) bool {
+	isDigit := func(b byte) bool { return b >= '0' && b <= '9' }
+	isSeparator := func(b byte) bool { return b == ' ' || b == '.' || b == '-' }
+
+	if start >= 2 && isDigit(text[start]) && isSeparator(text[start-1]) && isDigit(text[start-2]) {
+		return false
+	}
+	if end+1 < len(text) && isDigit(text[end-1]) && isSeparator(text[end]) && isDigit(text[end+1]) {
+		return false
+	}
+
+	return true
+}
+
+// digits returns the digits of the provided text.
+func digits(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text)
+}
+
+// isValidCardNumber returns true if the provided text is a payment card
+// number having a valid Luhn check digit.
+func isValidCardNumber(text string) bool {
+	number := digits(text)
+	if len(number) < 13 || len(number) > 19 {
+		return false
+	}
+
+	var sum int
+	for i := 0; i < len(number); i++ {
+		digit := int(number[len(number)-1-i] - '0')
+		if i%2 == 1 {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
+
+// isValidIBAN returns true if the provided text is an IBAN having valid
+// check digits.
+func isValidIBAN(text string) bool {
+	iban := strings.ReplaceAll(text, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	// Move the country code and the check digits to the end and replace
+	// the letters by numbers (A = 10, B = 11, ..., Z = 35).
+	var sb strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if unicode.IsLetter(r) {
+			fmt.Fprintf(&sb, "%d", r-'A'+10)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	if !ok {
+		return false
+	}
+
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// isValidSSN returns true if the provided text is a US Social Security
+// number which could have been issued. Numbers having the area number
+// 000, 666 or 900-999, the group number 00 or the serial number 0000 are
+// never issued.
+func isValidSSN(text string) bool {
+	number := digits(text)
+	if len(number) != 9 {
+		return false
+	}
+
+	area, group, serial := number[:3], number[3:5], number[5:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+// isValidPhoneNumber returns true if the provided text contains the number
+// of digits of a phone number, including the area code.
+func isValidPhoneNumber(text string) bool {
+	n := len(digits(text))
+	return n >= 10 && n <= 15
+}
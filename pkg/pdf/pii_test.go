@@ -0,0 +1,203 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsValidCardNumber(t *testing.T) {
+	testCases := []struct {
+		text string
+		want bool
+	}{
+		{"4111111111111111", true},
+		{"4242 4242 4242 4242", true},
+		{"5555-5555-5555-4444", true},
+		{"378282246310005", true},
+		{"6011111111111117", true},
+		{"4111111111111112", false},
+		{"4242 4242 4242 4241", false},
+		{"411111111111", false},
+		{"41111111111111111111", false},
+	}
+
+	for _, tc := range testCases {
+		if got := isValidCardNumber(tc.text); got != tc.want {
+			t.Errorf("isValidCardNumber(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestIsValidIBAN(t *testing.T) {
+	testCases := []struct {
+		text string
+		want bool
+	}{
+		{"GB82WEST12345698765432", true},
+		{"GB82 WEST 1234 5698 7654 32", true},
+		{"DE89 3704 0044 0532 0130 00", true},
+		{"FR14 2004 1010 0505 0001 3M02 606", true},
+		{"GB82 WEST 1234 5698 7654 33", false},
+		{"GB28 WEST 1234 5698 7654 32", false},
+		{"GB82 WEST 1234", false},
+	}
+
+	for _, tc := range testCases {
+		if got := isValidIBAN(tc.text); got != tc.want {
+			t.Errorf("isValidIBAN(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestIsValidSSN(t *testing.T) {
+	testCases := []struct {
+		text string
+		want bool
+	}{
+		{"123-45-6789", true},
+		{"123 45 6789", true},
+		{"000-45-6789", false},
+		{"666-45-6789", false},
+		{"900-45-6789", false},
+		{"999-45-6789", false},
+		{"123-00-6789", false},
+		{"123-45-0000", false},
+		{"123-45-678", false},
+	}
+
+	for _, tc := range testCases {
+		if got := isValidSSN(tc.text); got != tc.want {
+			t.Errorf("isValidSSN(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestIsSeparateNumber(t *testing.T) {
+	testCases := []struct {
+		text       string
+		start, end int
+		want       bool
+	}{
+		{"call 555-1234 now", 5, 13, true},
+		{"555-1234", 0, 8, true},
+		{"12 555-1234", 3, 11, false},
+		{"1-555-1234", 2, 10, false},
+		{"555-1234 56", 0, 8, false},
+		{"555-1234.5", 0, 8, false},
+		{"555-1234. Next", 0, 8, true},
+		{"a 555-1234", 2, 10, true},
+	}
+
+	for _, tc := range testCases {
+		if got := isSeparateNumber(tc.text, tc.start, tc.end); got != tc.want {
+			t.Errorf("isSeparateNumber(%q, %d, %d) = %v, want %v", tc.text, tc.start, tc.end, got, tc.want)
+		}
+	}
+}
+
+func TestPIIDetectorReplacer(t *testing.T) {
+	testCases := []struct {
+		name string
+		typ  string
+		text string
+		want []string
+	}{
+		{
+			name: "email",
+			typ:  "email",
+			text: "Contact: john.doe@example.co.uk, or jane@example.com.",
+			want: []string{"john.doe@example.co.uk", "jane@example.com"},
+		},
+		{
+			name: "valid card numbers",
+			typ:  "credit-card",
+			text: "Card 4111 1111 1111 1111, exp. 12/29. Card 5555-5555-5555-4444.",
+			want: []string{"4111 1111 1111 1111", "5555-5555-5555-4444"},
+		},
+		{
+			name: "invalid card number",
+			typ:  "credit-card",
+			text: "Card 4111 1111 1111 1112.",
+		},
+		{
+			name: "card number inside longer digit run",
+			typ:  "credit-card",
+			text: "Ref 94111111111111111 and 41111111111111110",
+		},
+		{
+			name: "card number inside longer separated number",
+			typ:  "credit-card",
+			text: "Ref 9 4111 1111 1111 1111",
+		},
+		{
+			name: "iban",
+			typ:  "iban",
+			text: "IBAN: GB82 WEST 1234 5698 7654 32 (GB), DE89370400440532013000.",
+			want: []string{"GB82 WEST 1234 5698 7654 32", "DE89370400440532013000"},
+		},
+		{
+			name: "invalid iban",
+			typ:  "iban",
+			text: "IBAN: GB82 WEST 1234 5698 7654 33",
+		},
+		{
+			name: "ssn",
+			typ:  "ssn",
+			text: "SSN 123-45-6789, not 666-45-6789 or 123-45-67890 or 0123-45-6789.",
+			want: []string{"123-45-6789"},
+		},
+		{
+			name: "phone",
+			typ:  "phone",
+			text: "Call (555) 123-4567 or +1 555-123-4567.",
+			want: []string{"(555) 123-4567", "+1 555-123-4567"},
+		},
+		{
+			name: "phone inside longer number",
+			typ:  "phone",
+			text: "Invoice 2024-555-123-4567-89",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			detectors, err := NewPIIDetectors([]string{tc.typ})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			replacer, err := detectors[0].replacer("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, match := range findMatches(tc.text, []*textReplacer{replacer}) {
+				got = append(got, tc.text[match.offset:match.offset+match.length])
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewPIIDetectors(t *testing.T) {
+	detectors, err := NewPIIDetectors(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detectors) != len(PIIDetectorTypes()) {
+		t.Errorf("got %d detectors, want %d", len(detectors), len(PIIDetectorTypes()))
+	}
+
+	if _, err := NewPIIDetectors([]string{"email", "passport"}); err == nil {
+		t.Error("expected error for unsupported PII type")
+	}
+}
@@ -0,0 +1,1086 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/unidoc/unipdf/v4/common"
+	unicontent "github.com/unidoc/unipdf/v4/contentstream"
+	unicore "github.com/unidoc/unipdf/v4/core"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// RedactArea represents an area of a page which is redacted.
+type RedactArea struct {
+	// Page is the number of the page containing the area.
+	Page int
+
+	// Rect is the redacted area, in user space units.
+	Rect Rect
+}
+
+// RedactOpts represents the options used for redacting content.
+type RedactOpts struct {
+	// Texts contains the redacted texts.
+	Texts []string
+
+	// Regexes contains regular expressions matching the redacted texts.
+	Regexes []string
+
+	// IgnoreCase specifies if the text search is case insensitive.
+	IgnoreCase bool
+
+	// Detectors contains PII detectors matching the redacted texts.
+	Detectors []*PIIDetector
+
+	// Areas contains the redacted page areas. All the text and the image
+	// pixels inside the areas are removed, along with the values of the form
+	// fields having widgets overlapping the areas.
+	Areas []*RedactArea
+
+	// Color is the color of the boxes drawn over the redacted content, in
+	// hex format (e.g. #000000). Defaults to black.
+	Color string
+
+	// OverlayText is the text drawn over the redaction boxes. The text also
+	// replaces the redacted text in annotations, form field values,
+	// bookmarks and metadata.
+	OverlayText string
+}
+
+// RedactResult contains the redactions made on a page.
+type RedactResult struct {
+	// The number of the page. Page 0 contains the redactions made outside
+	// of the pages (in form field values, bookmarks and metadata).
+	Page int
+
+	// The number of occurrences of the redacted texts found on the page.
+	Matches int
+
+	// The number of glyphs removed from the content streams of the page.
+	Glyphs int
+
+	// The number of images which were partially blanked out or removed.
+	Images int
+
+	// The number of annotations which were removed or had their text
+	// redacted.
+	Annotations int
+
+	// The number of occurrences of the redacted texts and the number of
+	// characters inside the redacted areas which could still be extracted
+	// from the page after redaction. Text drawn in ways which cannot be
+	// processed (e.g. using Type 3 fonts) is not removed.
+	Remaining int
+}
+
+// Redact removes the specified texts and areas from the PDF file specified
+// by the inputPath parameter and saves the result to the file specified by
+// the outputPath parameter. A password can be passed in for encrypted input
+// files.
+// The glyphs of the redacted text are removed from the content streams of
+// the pages, the pixels of the images overlapping the redacted content are
+// blanked out and boxes are drawn over the redacted content. Annotations
+// overlapping the redacted content are removed, and the redacted texts are
+// also removed from the remaining annotations, from the values of the form
+// fields, from the bookmarks and from the metadata of the file. The
+// appearances of the form field widgets which were changed or overlap the
+// redacted content are removed, so that viewers regenerate them.
+// The results contain the pages on which content was redacted, preceded by
+// the document level result (page 0), if any. The results still containing
+// redacted text after processing have a non-zero Remaining count.
+func Redact(inputPath, outputPath, password string, opts *RedactOpts) ([]*RedactResult, error) {
+	if opts == nil || len(opts.Texts)+len(opts.Regexes)+len(opts.Detectors)+len(opts.Areas) == 0 {
+		return nil, errors.New("no text or areas to redact specified")
+	}
+
+	color, err := parseColor(opts.Color, [3]float64{0, 0, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	// The replacers are used for finding the redacted text. The replacement
+	// text is only used for redacting annotations and metadata.
+	replacers, err := newRedactReplacers(opts, false)
+	if err != nil {
+		return nil, err
+	}
+	xmpReplacers, err := newRedactReplacers(opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return nil, err
+	}
+
+	areas := map[int][]Rect{}
+	for _, area := range opts.Areas {
+		if area.Page < 1 || area.Page > pageCount {
+			return nil, fmt.Errorf("invalid redaction area page: %d", area.Page)
+		}
+		areas[area.Page] = append(areas[area.Page], area.Rect)
+	}
+
+	var overlayFont *unipdf.PdfFont
+	if opts.OverlayText != "" {
+		if overlayFont, err = loadFont("", ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// Redact form field values.
+	doc := &RedactResult{}
+	form := newFormRedactor(r.AcroForm)
+	doc.Matches += form.redactValues(replacers)
+
+	// Redact pages.
+	var results []*RedactResult
+	for numPage := 1; numPage <= pageCount; numPage++ {
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return nil, err
+		}
+
+		rd := &pageRedactor{
+			page:        page,
+			replacers:   replacers,
+			areas:       areas[numPage],
+			color:       color,
+			overlayText: opts.OverlayText,
+			overlayFont: overlayFont,
+			form:        form,
+			opStrings:   map[*unicontent.ContentStreamOperation][]*redactString{},
+			opImages:    map[*unicontent.ContentStreamOperation]*redactImage{},
+			result:      &RedactResult{Page: numPage},
+		}
+		if err := rd.redact(); err != nil {
+			return nil, err
+		}
+
+		if res := rd.result; res.Matches+res.Glyphs+res.Images+res.Annotations+res.Remaining > 0 ||
+			len(rd.areas) > 0 {
+			results = append(results, res)
+		}
+	}
+
+	form.finish()
+	doc.Remaining += form.remaining(replacers)
+
+	// Copy input file contents.
+	w := unipdf.NewPdfWriter()
+	if err := readerToWriter(r, &w, nil); err != nil {
+		return nil, err
+	}
+
+	// Redact bookmarks.
+	outline := r.GetOutlineTree()
+	doc.Matches += replaceOutlineTitles(outline, replacers)
+	w.AddOutlineTree(outline)
+
+	// Redact metadata.
+	n, err := replaceMetadata(r, &w, replacers, xmpReplacers)
+	if err != nil {
+		return nil, err
+	}
+	doc.Matches += n
+
+	if doc.Matches+doc.Remaining > 0 {
+		results = append([]*RedactResult{doc}, results...)
+	}
+
+	// Write output file.
+	safe := inputPath == outputPath
+	return results, writePDF(outputPath, &w, safe)
+}
+
+// newRedactReplacers returns the replacers matching the redacted texts of
+// the provided options. The matches are replaced by the overlay text.
+// If the xml parameter is true, the literal texts and the overlay text are
+// XML escaped, in order to be used for XMP metadata.
+func newRedactReplacers(opts *RedactOpts, xml bool) ([]*textReplacer, error) {
+	replacement := opts.OverlayText
+	if xml {
+		replacement = xmlEscape(replacement)
+	}
+
+	var replacers []*textReplacer
+	for _, text := range opts.Texts {
+		if xml {
+			text = xmlEscape(text)
+		}
+
+		replacer, err := newTextReplacer(
+			&ReplacePair{Search: text, Replacement: replacement},
+			&ReplaceOpts{IgnoreCase: opts.IgnoreCase},
+		)
+		if err != nil {
+			return nil, err
+		}
+		replacers = append(replacers, replacer)
+	}
+	for _, regex := range opts.Regexes {
+		replacer, err := newTextReplacer(
+			&ReplacePair{Search: regex, Replacement: strings.ReplaceAll(replacement, "$", "$$")},
+			&ReplaceOpts{Regex: true, IgnoreCase: opts.IgnoreCase},
+		)
+		if err != nil {
+			return nil, err
+		}
+		replacers = append(replacers, replacer)
+	}
+	for _, detector := range opts.Detectors {
+		replacer, err := detector.replacer(replacement)
+		if err != nil {
+			return nil, err
+		}
+		replacers = append(replacers, replacer)
+	}
+
+	return replacers, nil
+}
+
+// redactGlyph represents a glyph drawn by a text showing operation.
+type redactGlyph struct {
+	// code contains the bytes of the character code of the glyph.
+	code []byte
+
+	// text is the text of the glyph and offset is the offset of the text
+	// in the text of the page.
+	text   string
+	offset int
+
+	// adv is the horizontal displacement of the glyph, in thousandths of a
+	// text space unit, as used by the TJ operator.
+	adv float64
+
+	// bbox is the bounding box of the glyph and endX and endY represent
+	// the position following the glyph, in user space.
+	bbox       Rect
+	endX, endY float64
+
+	removed bool
+}
+
+// redactString represents a string shown by a text showing operation.
+type redactString struct {
+	data   []byte
+	glyphs []*redactGlyph
+
+	// splittable specifies if the string can be split into the character
+	// codes of its glyphs.
+	splittable bool
+}
+
+// removed returns true if any of the glyphs of the string is removed.
+func (s *redactString) removed() bool {
+	for _, g := range s.glyphs {
+		if g.removed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactImage represents an image drawn by a content stream. The stream of
+// inline images is nil.
+type redactImage struct {
+	stream *unicore.PdfObjectStream
+	ctm    matrix
+}
+
+// pageRedactor redacts the content of a page.
+type pageRedactor struct {
+	page        *unipdf.PdfPage
+	replacers   []*textReplacer
+	areas       []Rect
+	color       [3]float64
+	overlayText string
+	overlayFont *unipdf.PdfFont
+	form        *formRedactor
+
+	// opStrings contains the strings shown by the text showing operations,
+	// indexed by the position of the strings in the operation parameters.
+	// opImages contains the images drawn by the operations.
+	opStrings map[*unicontent.ContentStreamOperation][]*redactString
+	opImages  map[*unicontent.ContentStreamOperation]*redactImage
+
+	text    strings.Builder
+	glyphs  []*redactGlyph
+	strings []*redactString
+	boxes   []Rect
+	result  *RedactResult
+}
+
+// redact redacts the page.
+func (rd *pageRedactor) redact() error {
+	contents, err := rd.page.GetAllContentStreams()
+	if err != nil {
+		return err
+	}
+
+	walker := &contentWalker{showText: rd.showText, drawImage: rd.drawImage, walkForms: true}
+	content, err := walker.walk(contents, rd.page.Resources)
+	if err != nil {
+		return err
+	}
+
+	// Find the redacted glyphs and the areas covered by them.
+	matches := findMatches(rd.text.String(), rd.replacers)
+	rd.result.Matches = len(matches)
+	rd.mark(matches)
+
+	for _, g := range rd.glyphs {
+		if !g.removed {
+			continue
+		}
+		rd.result.Glyphs++
+
+		if g.bbox.Width() > 0 && g.bbox.Height() > 0 {
+			rd.boxes = appendLineBBox(rd.boxes, g.bbox)
+		}
+	}
+	rd.boxes = append(rd.boxes, rd.areas...)
+
+	// Remove the redacted content.
+	if len(rd.boxes) > 0 {
+		changed, err := rd.rewrite(content)
+		if err != nil {
+			return err
+		}
+		if changed {
+			if err := rd.page.SetContentStreams([]string{content.ops.String()}, unicore.NewFlateEncoder()); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Check the text which can still be extracted from the page. The check
+	// also covers the text which could not be processed (e.g. text drawn
+	// using fonts which could not be loaded).
+	pageText, err := newPageText(rd.page, rd.result.Page)
+	if err != nil {
+		return err
+	}
+
+	rd.result.Remaining = len(findMatches(pageText.Text, rd.replacers))
+	for _, mark := range pageText.Marks {
+		if !mark.Meta && containsCenter(rd.areas, mark.BBox) {
+			rd.result.Remaining++
+		}
+	}
+
+	// Redact annotations.
+	if err := rd.redactAnnotations(); err != nil {
+		return err
+	}
+
+	// Draw redaction boxes.
+	if len(rd.boxes) == 0 {
+		return nil
+	}
+
+	return rd.drawBoxes()
+}
+
+// showText processes the glyphs of the provided string, shown by the
+// specified operation. Strings drawn using fonts which cannot be loaded are
+// skipped.
+func (rd *pageRedactor) showText(op *unicontent.ContentStreamOperation, index int, str *unicore.PdfObjectString,
+	glyphs []*textGlyph, _ *contentState) {
+	if glyphs == nil {
+		return
+	}
+
+	s := &redactString{data: str.Bytes(), splittable: len(glyphs) > 0}
+	for _, tg := range glyphs {
+		if tg.code == nil {
+			s.splittable = false
+		}
+
+		g := &redactGlyph{code: tg.code, text: tg.text, adv: tg.adv}
+		g.bbox = tg.trm.transformRect(Rect{Llx: 0, Lly: -0.25, Urx: tg.width / 1000, Ury: 0.9})
+		rd.addGlyph(g, tg.trm)
+		g.endX, g.endY = tg.trm.transform(tg.width/1000, 0)
+
+		s.glyphs = append(s.glyphs, g)
+	}
+	rd.strings = append(rd.strings, s)
+
+	strs := rd.opStrings[op]
+	for len(strs) <= index {
+		strs = append(strs, nil)
+	}
+	strs[index] = s
+	rd.opStrings[op] = strs
+}
+
+// drawImage records the image drawn by the specified operation. The stream
+// of inline images is nil.
+func (rd *pageRedactor) drawImage(op *unicontent.ContentStreamOperation, stream *unicore.PdfObjectStream,
+	state *contentState) error {
+	rd.opImages[op] = &redactImage{stream: stream, ctm: state.ctm}
+	return nil
+}
+
+// addGlyph adds the provided glyph, drawn using the specified text
+// rendering matrix, to the text of the page. A space is inserted before the
+// glyph if it is not adjacent to the previous glyph, in order to be able to
+// match text spanning multiple words.
+func (rd *pageRedactor) addGlyph(g *redactGlyph, trm matrix) {
+	if n := len(rd.glyphs); n > 0 && g.text != "" {
+		last := rd.glyphs[n-1]
+		x, y := trm.transform(0, 0)
+		dx, dy := x-last.endX, y-last.endY
+
+		norm, height := math.Hypot(trm[0], trm[1]), math.Hypot(trm[2], trm[3])
+		if norm > 0 && height > 0 {
+			along := (dx*trm[0] + dy*trm[1]) / norm
+			across := (dy*trm[0] - dx*trm[1]) / norm
+
+			if math.Abs(across) > height/2 || along > 0.2*height || along < -height {
+				text := rd.text.String()
+				prev, _ := utf8.DecodeLastRuneInString(text)
+				next, _ := utf8.DecodeRuneInString(g.text)
+				if text != "" && !unicode.IsSpace(prev) && !unicode.IsSpace(next) {
+					rd.text.WriteByte(' ')
+				}
+			}
+		}
+	}
+
+	g.offset = rd.text.Len()
+	rd.text.WriteString(g.text)
+	rd.glyphs = append(rd.glyphs, g)
+}
+
+// mark marks the glyphs overlapping the provided matches or having their
+// centers inside the redacted areas as removed. Strings which cannot be
+// split are removed entirely.
+func (rd *pageRedactor) mark(matches []*textMatch) {
+	for _, g := range rd.glyphs {
+		start, end := g.offset, g.offset+len(g.text)
+
+		i := sort.Search(len(matches), func(i int) bool {
+			return matches[i].offset+matches[i].length > start
+		})
+		if i < len(matches) {
+			// Glyphs without text are removed if they are inside a match.
+			if m := matches[i]; (g.text != "" && m.offset < end) || (g.text == "" && m.offset < start) {
+				g.removed = true
+			}
+		}
+
+		if containsCenter(rd.areas, g.bbox) {
+			g.removed = true
+		}
+	}
+
+	for _, s := range rd.strings {
+		if s.splittable || !s.removed() {
+			continue
+		}
+		for _, g := range s.glyphs {
+			g.removed = true
+		}
+	}
+}
+
+// containsCenter returns true if the center of the provided rectangle is
+// inside any of the specified areas.
+func containsCenter(areas []Rect, rect Rect) bool {
+	x, y := (rect.Llx+rect.Urx)/2, (rect.Lly+rect.Ury)/2
+	for _, area := range areas {
+		if x >= area.Llx && x <= area.Urx && y >= area.Lly && y <= area.Ury {
+			return true
+		}
+	}
+
+	return false
+}
+
+// overlaps returns true if the provided rectangle overlaps any of the
+// redaction boxes.
+func (rd *pageRedactor) overlaps(rect Rect) bool {
+	return overlapsAny(rd.boxes, rect)
+}
+
+// overlapsAny returns true if the provided rectangle overlaps any of the
+// specified areas.
+func overlapsAny(areas []Rect, rect Rect) bool {
+	for _, area := range areas {
+		if rect.intersects(area) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rewrite removes the redacted glyphs and images from the specified
+// content. Returns true if the content was changed.
+func (rd *pageRedactor) rewrite(c *walkedContent) (bool, error) {
+	var changed bool
+	ops := make(unicontent.ContentStreamOperations, 0, len(*c.ops))
+	for _, op := range *c.ops {
+		if strs, ok := rd.opStrings[op]; ok && anyRemoved(strs) {
+			ops = append(ops, redactTextOp(op, strs)...)
+			changed = true
+			continue
+		}
+
+		if img, ok := rd.opImages[op]; ok && rd.overlaps(img.ctm.unitBBox()) {
+			rd.result.Images++
+			changed = true
+
+			// Inline images, and images which cannot be blanked out, are
+			// removed.
+			if img.stream == nil {
+				continue
+			}
+
+			stream, err := redactImageStream(img.stream, img.ctm, rd.boxes)
+			if err != nil {
+				common.Log.Debug("ERROR: could not redact image, removing it: %v", err)
+				continue
+			}
+
+			name := resourceName("ImRedacted", c.resources.HasXObjectByName)
+			if err := c.resources.SetXObjectByName(name, stream); err != nil {
+				return false, err
+			}
+
+			ops = append(ops, &unicontent.ContentStreamOperation{
+				Operand: "Do",
+				Params:  []unicore.PdfObject{unicore.MakeName(string(name))},
+			})
+			continue
+		}
+
+		if form, ok := c.forms[op]; ok {
+			formChanged, err := rd.rewrite(form.content)
+			if err != nil {
+				return false, err
+			}
+
+			// The form is copied, as it might be drawn in other places.
+			if formChanged {
+				stream, err := copyStream(form.stream, []byte(form.content.ops.String()))
+				if err != nil {
+					return false, err
+				}
+
+				name := resourceName("FmRedacted", c.resources.HasXObjectByName)
+				if err := c.resources.SetXObjectByName(name, stream); err != nil {
+					return false, err
+				}
+
+				ops = append(ops, &unicontent.ContentStreamOperation{
+					Operand: "Do",
+					Params:  []unicore.PdfObject{unicore.MakeName(string(name))},
+				})
+				changed = true
+				continue
+			}
+		}
+
+		ops = append(ops, op)
+	}
+
+	if changed {
+		*c.ops = ops
+	}
+
+	return changed, nil
+}
+
+// anyRemoved returns true if any of the provided strings has removed glyphs.
+func anyRemoved(strs []*redactString) bool {
+	for _, s := range strs {
+		if s != nil && s.removed() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactTextOp returns the operations replacing the specified text showing
+// operation. The removed glyphs are replaced by positioning adjustments, so
+// that the position of the remaining glyphs does not change.
+func redactTextOp(op *unicontent.ContentStreamOperation, strs []*redactString) []*unicontent.ContentStreamOperation {
+	var elems []unicore.PdfObject
+	switch op.Operand {
+	case "TJ":
+		arr, _ := unicore.GetArray(op.Params[0])
+		elems = arr.Elements()
+	default:
+		elems = op.Params
+	}
+
+	var arr []unicore.PdfObject
+	var buf []byte
+	var adj float64
+
+	flushBuf := func() {
+		if len(buf) > 0 {
+			arr = append(arr, unicore.MakeStringFromBytes(buf))
+			buf = nil
+		}
+	}
+	flushAdj := func() {
+		if adj != 0 {
+			arr = append(arr, unicore.MakeFloat(adj))
+			adj = 0
+		}
+	}
+
+	for i, elem := range elems {
+		var s *redactString
+		if i < len(strs) {
+			s = strs[i]
+		}
+
+		if s == nil {
+			// Skip the word and character spacing parameters of the ''
+			// operator.
+			if op.Operand != "TJ" && i < len(elems)-1 {
+				continue
+			}
+
+			if val, err := unicore.GetNumberAsFloat(elem); err == nil {
+				flushBuf()
+				adj += val
+				continue
+			}
+
+			flushBuf()
+			flushAdj()
+			arr = append(arr, elem)
+			continue
+		}
+
+		if !s.splittable && !s.removed() {
+			flushAdj()
+			buf = append(buf, s.data...)
+			continue
+		}
+
+		for _, g := range s.glyphs {
+			if g.removed {
+				flushBuf()
+				adj -= g.adv
+				continue
+			}
+
+			flushAdj()
+			buf = append(buf, g.code...)
+		}
+	}
+	flushBuf()
+	flushAdj()
+
+	tj := &unicontent.ContentStreamOperation{
+		Operand: "TJ",
+		Params:  []unicore.PdfObject{unicore.MakeArray(arr...)},
+	}
+	nextLine := &unicontent.ContentStreamOperation{Operand: "T*"}
+
+	switch op.Operand {
+	case "'":
+		return []*unicontent.ContentStreamOperation{nextLine, tj}
+	case "''", `"`:
+		return []*unicontent.ContentStreamOperation{
+			{Operand: "Tw", Params: []unicore.PdfObject{op.Params[0]}},
+			{Operand: "Tc", Params: []unicore.PdfObject{op.Params[1]}},
+			nextLine,
+			tj,
+		}
+	}
+
+	return []*unicontent.ContentStreamOperation{tj}
+}
+
+// redactImageStream returns a copy of the specified image XObject, in which
+// the pixels inside the provided boxes are blanked out. The image is drawn
+// using the specified transformation matrix.
+func redactImageStream(stream *unicore.PdfObjectStream, ctm matrix, boxes []Rect) (*unicore.PdfObjectStream, error) {
+	ximg, err := unipdf.NewXObjectImageFromStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	var width, height, bpc, comps int
+	if ximg.Width != nil {
+		width = int(*ximg.Width)
+	}
+	if ximg.Height != nil {
+		height = int(*ximg.Height)
+	}
+	if ximg.BitsPerComponent != nil {
+		bpc = int(*ximg.BitsPerComponent)
+	}
+	if ximg.ColorSpace != nil {
+		comps = ximg.ColorSpace.GetNumComponents()
+	}
+	if mask, _ := unicore.GetBoolVal(ximg.ImageMask); mask {
+		bpc, comps = 1, 1
+	}
+	if width <= 0 || height <= 0 || bpc <= 0 || comps <= 0 {
+		return nil, errors.New("unsupported image format")
+	}
+
+	inv, ok := ctm.inverse()
+	if !ok {
+		return nil, errors.New("invalid image transformation matrix")
+	}
+
+	data, err := unicore.DecodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < (width*comps*bpc+7)/8*height {
+		return nil, errors.New("invalid image data length")
+	}
+	blankImageAreas(data, width, height, comps, bpc, inv, boxes)
+
+	redacted, err := copyStream(stream, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Redact the soft mask of the image.
+	if smask, ok := unicore.GetStream(stream.Get("SMask")); ok {
+		redactedMask, err := redactImageStream(smask, ctm, boxes)
+		if err != nil {
+			return nil, err
+		}
+		redacted.Set("SMask", redactedMask)
+	}
+
+	return redacted, nil
+}
+
+// blankImageAreas clears the samples of the pixels of the provided image
+// data which are inside the specified boxes. The image has the specified
+// size, number of color components and bits per component, and the inv
+// parameter is the inverse of the matrix used for drawing it.
+func blankImageAreas(data []byte, width, height, comps, bpc int, inv matrix, boxes []Rect) {
+	stride := (width*comps*bpc + 7) / 8
+
+	// The image is mapped onto the unit square of the image space, with the
+	// first row of the image at the top.
+	clamp := func(val float64, max int) int {
+		return int(math.Max(0, math.Min(float64(max), val)))
+	}
+	for _, box := range boxes {
+		r := inv.transformRect(box)
+		x0, x1 := clamp(math.Floor(r.Llx*float64(width)), width), clamp(math.Ceil(r.Urx*float64(width)), width)
+		y0, y1 := clamp(math.Floor((1-r.Ury)*float64(height)), height), clamp(math.Ceil((1-r.Lly)*float64(height)), height)
+
+		for y := y0; y < y1; y++ {
+			row := data[y*stride : (y+1)*stride]
+			for bit := x0 * comps * bpc; bit < x1*comps*bpc; bit++ {
+				row[bit/8] &^= 0x80 >> uint(bit%8)
+			}
+		}
+	}
+}
+
+// redactAnnotations removes the annotations of the page which overlap the
+// redaction boxes, along with their popups, and redacts the text of the
+// remaining annotations. Widget (form field) annotations are kept, but their
+// appearances are removed if they overlap the redaction boxes, and the
+// values of their fields are removed if they overlap the redacted areas.
+func (rd *pageRedactor) redactAnnotations() error {
+	annots, err := rd.page.GetAnnotations()
+	if err != nil {
+		return err
+	}
+
+	removed := map[*unicore.PdfObjectDictionary]bool{}
+	for _, annot := range annots {
+		dict, ok := unicore.GetDict(annot.GetContainingPdfObject())
+		if !ok {
+			continue
+		}
+
+		subtype, _ := unicore.GetNameVal(dict.Get("Subtype"))
+		if subtype != "Popup" && subtype != "Widget" && rd.overlaps(annotationRect(dict)) {
+			removed[dict] = true
+			rd.result.Annotations++
+		}
+	}
+
+	kept := make([]*unipdf.PdfAnnotation, 0, len(annots))
+	for _, annot := range annots {
+		dict, ok := unicore.GetDict(annot.GetContainingPdfObject())
+		if !ok {
+			kept = append(kept, annot)
+			continue
+		}
+		if removed[dict] {
+			continue
+		}
+
+		subtype, _ := unicore.GetNameVal(dict.Get("Subtype"))
+		switch subtype {
+		case "Popup":
+			if parent, ok := unicore.GetDict(dict.Get("Parent")); ok && removed[parent] {
+				continue
+			}
+		case "Widget":
+			rect := annotationRect(dict)
+			if rd.form.redactWidget(annot, dict, rd.overlaps(rect), overlapsAny(rd.areas, rect)) {
+				rd.result.Annotations++
+			}
+		default:
+			if replaceAnnotationText(annot, dict, rd.replacers) > 0 {
+				rd.result.Annotations++
+			}
+		}
+
+		kept = append(kept, annot)
+	}
+
+	if len(kept) != len(annots) {
+		rd.page.SetAnnotations(kept)
+	}
+
+	return nil
+}
+
+// formRedactor redacts the values of the form fields of a document and the
+// appearances of their widgets.
+type formRedactor struct {
+	form *unipdf.PdfAcroForm
+
+	// fields contains the fields of the widget annotations. changed contains
+	// the widgets which had their appearances removed.
+	fields  map[*unicore.PdfObjectDictionary]*unipdf.PdfField
+	changed map[*unicore.PdfObjectDictionary]bool
+}
+
+// newFormRedactor returns a new redactor for the provided form, which can
+// be nil.
+func newFormRedactor(form *unipdf.PdfAcroForm) *formRedactor {
+	fr := &formRedactor{
+		form:    form,
+		fields:  map[*unicore.PdfObjectDictionary]*unipdf.PdfField{},
+		changed: map[*unicore.PdfObjectDictionary]bool{},
+	}
+	if form == nil {
+		return fr
+	}
+
+	for _, field := range form.AllFields() {
+		for _, widget := range field.Annotations {
+			if dict, ok := unicore.GetDict(widget.GetContainingPdfObject()); ok {
+				fr.fields[dict] = field
+			}
+		}
+	}
+
+	return fr
+}
+
+// redactValues replaces the matches of the specified replacers in the
+// values of the form fields and removes the appearances of the widgets of
+// the changed fields. Returns the number of replacements.
+func (fr *formRedactor) redactValues(replacers []*textReplacer) int {
+	if fr.form == nil {
+		return 0
+	}
+
+	var count int
+	for _, field := range fr.form.AllFields() {
+		if n := replaceFieldValue(field, replacers); n > 0 {
+			fr.removeAppearances(field)
+			count += n
+		}
+	}
+
+	return count
+}
+
+// redactWidget redacts the provided widget annotation. The appearance of the
+// widget is removed if it overlaps the redaction boxes, and the value of its
+// field is removed if it overlaps the redacted areas. Returns true if the
+// appearance of the widget was removed.
+func (fr *formRedactor) redactWidget(annot *unipdf.PdfAnnotation, dict *unicore.PdfObjectDictionary,
+	overlapsBoxes, overlapsAreas bool) bool {
+	if field := fr.fields[dict]; field != nil && overlapsAreas {
+		fr.clearValue(field)
+	}
+	if overlapsBoxes && !fr.changed[dict] {
+		fr.removeAppearance(annot, dict)
+	}
+
+	return fr.changed[dict]
+}
+
+// clearValue removes the value of the provided field. Fields inheriting
+// their value have the value of the ancestor field defining it removed.
+func (fr *formRedactor) clearValue(field *unipdf.PdfField) {
+	for ; field != nil; field = field.Parent {
+		if field.V == nil {
+			continue
+		}
+
+		// Button field values are names, which are reset to the off state.
+		if _, ok := unicore.GetName(field.V); ok {
+			field.V = unicore.MakeName("Off")
+		} else {
+			field.V = unicore.MakeString("")
+		}
+		if dict, ok := unicore.GetDict(field.GetContainingPdfObject()); ok {
+			dict.Set("V", field.V)
+			dict.Remove("RV")
+		}
+
+		fr.removeAppearances(field)
+		return
+	}
+}
+
+// removeAppearances removes the appearances of the widgets of the provided
+// field and of its descendants.
+func (fr *formRedactor) removeAppearances(field *unipdf.PdfField) {
+	for _, widget := range field.Annotations {
+		if dict, ok := unicore.GetDict(widget.GetContainingPdfObject()); ok {
+			fr.removeAppearance(widget.PdfAnnotation, dict)
+		}
+	}
+	for _, kid := range field.Kids {
+		fr.removeAppearances(kid)
+	}
+}
+
+// removeAppearance removes the appearance of the provided widget, so that
+// viewers regenerate it from the value of its field.
+func (fr *formRedactor) removeAppearance(annot *unipdf.PdfAnnotation, dict *unicore.PdfObjectDictionary) {
+	annot.AP = nil
+	dict.Remove("AP")
+	fr.changed[dict] = true
+}
+
+// finish marks the appearances of the form as needing regeneration, if any
+// of them were removed.
+func (fr *formRedactor) finish() {
+	if fr.form != nil && len(fr.changed) > 0 {
+		fr.form.NeedAppearances = unicore.MakeBool(true)
+	}
+}
+
+// remaining returns the number of matches of the specified replacers which
+// can still be found in the values of the form fields (e.g. in rich text
+// values stored in streams).
+func (fr *formRedactor) remaining(replacers []*textReplacer) int {
+	if fr.form == nil {
+		return 0
+	}
+
+	var count int
+	for _, field := range fr.form.AllFields() {
+		texts := []string{objectText(field.V)}
+		if dict, ok := unicore.GetDict(field.GetContainingPdfObject()); ok {
+			texts = append(texts, objectText(dict.Get("RV")))
+		}
+
+		for _, text := range texts {
+			count += len(findMatches(text, replacers))
+		}
+	}
+
+	return count
+}
+
+// objectText returns the text contained by the provided string, array of
+// strings or stream object.
+func objectText(obj unicore.PdfObject) string {
+	switch t := unicore.TraceToDirectObject(obj).(type) {
+	case *unicore.PdfObjectString:
+		return t.Decoded()
+	case *unicore.PdfObjectArray:
+		var texts []string
+		for _, elem := range t.Elements() {
+			texts = append(texts, objectText(elem))
+		}
+		return strings.Join(texts, "\n")
+	case *unicore.PdfObjectStream:
+		data, err := unicore.DecodeStream(t)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	return ""
+}
+
+// drawBoxes draws the redaction boxes over the content of the page, along
+// with the overlay text, if specified.
+func (rd *pageRedactor) drawBoxes() error {
+	if rd.page.Resources == nil {
+		rd.page.Resources = unipdf.NewPdfPageResources()
+	}
+
+	color := rd.color
+	cc := unicontent.NewContentCreator()
+	cc.Add_q()
+	cc.Add_rg(color[0], color[1], color[2])
+	for _, box := range rd.boxes {
+		cc.Add_re(box.Llx, box.Lly, box.Width(), box.Height())
+	}
+	cc.Add_f()
+
+	if rd.overlayFont != nil {
+		fontName := resourceName("FRedact", rd.page.Resources.HasFontByName)
+		if err := rd.page.Resources.SetFontByName(fontName, rd.overlayFont.ToPdfObject()); err != nil {
+			return err
+		}
+		encoded, _ := rd.overlayFont.StringToCharcodeBytes(rd.overlayText)
+
+		// Use white text over dark boxes and black text otherwise.
+		var textColor float64
+		if 0.299*color[0]+0.587*color[1]+0.114*color[2] < 0.5 {
+			textColor = 1
+		}
+
+		for _, box := range rd.boxes {
+			fontSize := 0.8 * box.Height()
+			width := textWidth(rd.overlayFont, rd.overlayText, fontSize)
+			if maxWidth := 0.95 * box.Width(); width > maxWidth {
+				fontSize *= maxWidth / width
+				width = maxWidth
+			}
+			if fontSize <= 0 {
+				continue
+			}
+
+			cc.Add_BT()
+			cc.Add_rg(textColor, textColor, textColor)
+			cc.Add_Tf(fontName, fontSize)
+			cc.Add_Td(box.Llx+(box.Width()-width)/2, box.Lly+(box.Height()-0.7*fontSize)/2)
+			cc.Add_Tj(*unicore.MakeStringFromBytes(encoded))
+			cc.Add_ET()
+		}
+	}
+	cc.Add_Q()
+
+	return addPageContents(rd.page, cc.String(), false)
+}
@@ -0,0 +1,322 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	unicontent "github.com/unidoc/unipdf/v4/contentstream"
+	unicore "github.com/unidoc/unipdf/v4/core"
+)
+
+// newTestRedactor returns a page redactor for the provided strings, each
+// glyph of which has a single byte character code, the text of the glyph
+// and an advance of 500. Unsplittable strings are prefixed with "!".
+func newTestRedactor(strs ...string) *pageRedactor {
+	rd := &pageRedactor{}
+	for _, str := range strs {
+		splittable := !strings.HasPrefix(str, "!")
+		str = strings.TrimPrefix(str, "!")
+
+		s := &redactString{data: []byte(str), splittable: splittable}
+		for i := 0; i < len(str); i++ {
+			g := &redactGlyph{code: []byte{str[i]}, text: str[i : i+1], adv: 500, offset: rd.text.Len()}
+			rd.text.WriteString(g.text)
+			rd.glyphs = append(rd.glyphs, g)
+			s.glyphs = append(s.glyphs, g)
+		}
+		rd.strings = append(rd.strings, s)
+	}
+
+	return rd
+}
+
+// removedText returns the text of the provided glyphs, in which the removed
+// glyphs are replaced by "_".
+func removedText(glyphs []*redactGlyph) string {
+	var sb strings.Builder
+	for _, g := range glyphs {
+		if g.removed {
+			sb.WriteByte('_')
+		} else {
+			sb.WriteString(g.text)
+		}
+	}
+
+	return sb.String()
+}
+
+func TestPageRedactorMark(t *testing.T) {
+	testCases := []struct {
+		name   string
+		strs   []string
+		search string
+		want   string
+	}{
+		{
+			name:   "single string",
+			strs:   []string{"the secret code"},
+			search: "secret",
+			want:   "the ______ code",
+		},
+		{
+			name:   "match across kerned strings",
+			strs:   []string{"the se", "cr", "et code"},
+			search: "secret",
+			want:   "the ______ code",
+		},
+		{
+			name:   "multiple matches",
+			strs:   []string{"secret and secret"},
+			search: "secret",
+			want:   "______ and ______",
+		},
+		{
+			name:   "unsplittable string",
+			strs:   []string{"the ", "!secret code", " here"},
+			search: "secret",
+			want:   "the ___________ here",
+		},
+		{
+			name:   "no match",
+			strs:   []string{"public", " text"},
+			search: "secret",
+			want:   "public text",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			replacer, err := newTextReplacer(&ReplacePair{Search: tc.search}, &ReplaceOpts{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rd := newTestRedactor(tc.strs...)
+			rd.mark(findMatches(rd.text.String(), []*textReplacer{replacer}))
+
+			if got := removedText(rd.glyphs); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPageRedactorMarkAreas(t *testing.T) {
+	rd := newTestRedactor("abcd")
+	for i, g := range rd.glyphs {
+		x := float64(i * 10)
+		g.bbox = Rect{Llx: x, Lly: 0, Urx: x + 10, Ury: 10}
+	}
+	rd.areas = []Rect{{Llx: 12, Lly: 0, Urx: 30, Ury: 10}}
+	rd.mark(nil)
+
+	if got, want := removedText(rd.glyphs), "a__d"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// formatOps returns a textual representation of the provided operations,
+// in which strings are enclosed in parentheses.
+func formatOps(ops []*unicontent.ContentStreamOperation) string {
+	var format func(obj unicore.PdfObject) string
+	format = func(obj unicore.PdfObject) string {
+		if str, ok := unicore.GetString(obj); ok {
+			return "(" + str.String() + ")"
+		}
+		if arr, ok := unicore.GetArray(obj); ok {
+			var elems []string
+			for _, elem := range arr.Elements() {
+				elems = append(elems, format(elem))
+			}
+			return "[" + strings.Join(elems, " ") + "]"
+		}
+		if val, err := unicore.GetNumberAsFloat(obj); err == nil {
+			return fmt.Sprintf("%g", val)
+		}
+		return obj.String()
+	}
+
+	var parts []string
+	for _, op := range ops {
+		var params []string
+		for _, param := range op.Params {
+			params = append(params, format(param))
+		}
+		parts = append(parts, strings.TrimSpace(strings.Join(params, " ")+" "+op.Operand))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestRedactTextOp(t *testing.T) {
+	testCases := []struct {
+		name    string
+		operand string
+		// params contains the operation parameters. Strings are shown
+		// strings and float64 values are numbers.
+		params []interface{}
+		search string
+		want   string
+	}{
+		{
+			name:    "Tj",
+			operand: "Tj",
+			params:  []interface{}{"abcde"},
+			search:  "bc",
+			want:    "[(a) -1000 (de)] TJ",
+		},
+		{
+			name:    "Tj at string start",
+			operand: "Tj",
+			params:  []interface{}{"abc"},
+			search:  "a",
+			want:    "[-500 (bc)] TJ",
+		},
+		{
+			name:    "TJ kerning is kept",
+			operand: "TJ",
+			params:  []interface{}{"the se", -50.0, "cr", 20.0, "et code"},
+			search:  "secret",
+			want:    "[(the ) -3030 ( code)] TJ",
+		},
+		{
+			name:    "TJ untouched strings",
+			operand: "TJ",
+			params:  []interface{}{"public", -120.0, "secret"},
+			search:  "secret",
+			want:    "[(public) -3120] TJ",
+		},
+		{
+			name:    "quote",
+			operand: "'",
+			params:  []interface{}{"top secret"},
+			search:  "secret",
+			want:    "T* [(top ) -3000] TJ",
+		},
+		{
+			name:    "double quote",
+			operand: `"`,
+			params:  []interface{}{2.0, 1.0, "top secret"},
+			search:  "top",
+			want:    "2 Tw 1 Tc T* [-1500 ( secret)] TJ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Build the operation and the redacted strings.
+			var texts []string
+			var objs []unicore.PdfObject
+			for _, param := range tc.params {
+				switch v := param.(type) {
+				case string:
+					texts = append(texts, v)
+					objs = append(objs, unicore.MakeString(v))
+				case float64:
+					objs = append(objs, unicore.MakeFloat(v))
+				}
+			}
+
+			op := &unicontent.ContentStreamOperation{Operand: tc.operand, Params: objs}
+			if tc.operand == "TJ" {
+				op.Params = []unicore.PdfObject{unicore.MakeArray(objs...)}
+			}
+
+			rd := newTestRedactor(texts...)
+			replacer, err := newTextReplacer(&ReplacePair{Search: tc.search}, &ReplaceOpts{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd.mark(findMatches(rd.text.String(), []*textReplacer{replacer}))
+
+			strs := make([]*redactString, len(tc.params))
+			var s int
+			for i, param := range tc.params {
+				if _, ok := param.(string); ok {
+					strs[i] = rd.strings[s]
+					s++
+				}
+			}
+
+			if got := formatOps(redactTextOp(op, strs)); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBlankImageAreas(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		width, height, comps, bpc int
+		ctm                       matrix
+		boxes                     []Rect
+		want                      []byte
+	}{
+		{
+			name:  "1 bpc",
+			width: 16, height: 1, comps: 1, bpc: 1,
+			ctm:   matrix{16, 0, 0, 1, 0, 0},
+			boxes: []Rect{{Llx: 4, Lly: 0, Urx: 12, Ury: 1}},
+			want:  []byte{0xf0, 0x0f},
+		},
+		{
+			name:  "1 bpc row padding",
+			width: 4, height: 2, comps: 1, bpc: 1,
+			ctm:   matrix{4, 0, 0, 2, 0, 0},
+			boxes: []Rect{{Llx: 0, Lly: 0, Urx: 2, Ury: 1}},
+			want:  []byte{0xff, 0x3f},
+		},
+		{
+			name:  "8 bpc",
+			width: 4, height: 2, comps: 1, bpc: 8,
+			ctm:   matrix{4, 0, 0, 2, 0, 0},
+			boxes: []Rect{{Llx: 0, Lly: 1, Urx: 2, Ury: 2}},
+			want:  []byte{0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name:  "8 bpc RGB translated",
+			width: 2, height: 1, comps: 3, bpc: 8,
+			ctm:   matrix{2, 0, 0, 1, 100, 100},
+			boxes: []Rect{{Llx: 101, Lly: 100, Urx: 110, Ury: 101}},
+			want:  []byte{0xff, 0xff, 0xff, 0, 0, 0},
+		},
+		{
+			name:  "16 bpc RGB",
+			width: 2, height: 1, comps: 3, bpc: 16,
+			ctm:   matrix{2, 0, 0, 1, 0, 0},
+			boxes: []Rect{{Llx: 0, Lly: 0, Urx: 1, Ury: 1}},
+			want:  []byte{0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name:  "box outside of image",
+			width: 2, height: 1, comps: 1, bpc: 8,
+			ctm:   matrix{2, 0, 0, 1, 0, 0},
+			boxes: []Rect{{Llx: 10, Lly: 10, Urx: 20, Ury: 20}},
+			want:  []byte{0xff, 0xff},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inv, ok := tc.ctm.inverse()
+			if !ok {
+				t.Fatal("matrix is not invertible")
+			}
+
+			data := bytes.Repeat([]byte{0xff}, len(tc.want))
+			blankImageAreas(data, tc.width, tc.height, tc.comps, tc.bpc, inv, tc.boxes)
+
+			if !bytes.Equal(data, tc.want) {
+				t.Errorf("got %x, want %x", data, tc.want)
+			}
+		})
+	}
+}
@@ -6,13 +6,11 @@
 package pdf
 
 import (
-	"archive/zip"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"io"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -28,13 +26,19 @@ type RenderOpts struct {
 	// ImageQuality specifies the quality of the rendered images.
 	// Only applies to rendered JPEG images.
 	ImageQuality int
+
+	// OutputOpts specifies the output directory and the naming of the
+	// rendered images.
+	OutputOpts
 }
 
 // Render renders the pages of the PDF file specified by the inputPath parameter
-// to image targets. The rendered images are saved as a ZIP archive at the
-// location specified by the outputPath parameter.
+// to image targets. The rendered images are saved as a ZIP or TAR archive at
+// the location specified by the outputPath parameter, or in the output
+// directory specified by the opts parameter.
 // A password can be passed in, if the input file is encrypted.
 // If the pages parameter is nil or an empty slice, all pages are rendered.
+// The function returns the output location.
 func Render(inputPath, outputPath, password string, pages []int, opts *RenderOpts) (string, error) {
 	// Use input file directory if no output path is specified.
 	dir, inputFile := filepath.Split(inputPath)
@@ -81,39 +85,47 @@ func Render(inputPath, outputPath, password string, pages []int, opts *RenderOpt
 		return "", fmt.Errorf("unsupported image format: %s", opts.ImageFormat)
 	}
 
-	// Prepare output archive.
-	outputFile, err := os.Create(outputPath)
+	// Prepare output location.
+	fw, outputPath, err := opts.writer(outputPath)
 	if err != nil {
 		return "", err
 	}
-	defer outputFile.Close()
-
-	zw := zip.NewWriter(outputFile)
 
 	// Render pages.
 	device := render.NewImageDevice()
+	namer := opts.namer(inputPath, "{name}_{page}.{ext}")
 	for _, numPage := range pages {
 		// Get page.
 		page, err := r.GetPage(numPage)
 		if err != nil {
+			fw.Close()
 			return "", err
 		}
 
 		// Render page to image.
 		img, err := device.Render(page)
 		if err != nil {
+			fw.Close()
+			return "", err
+		}
+
+		// Add rendered image to output location.
+		name, err := namer.next(numPage, 0, imgExt)
+		if err != nil {
+			fw.Close()
 			return "", err
 		}
 
-		// Add rendered image to zip file.
-		file, err := zw.Create(fmt.Sprintf("%s_%d.%s", inputFile, numPage, imgExt))
+		file, err := fw.Create(name)
 		if err != nil {
+			fw.Close()
 			return "", err
 		}
 		if err := encodeFunc(file, img); err != nil {
+			fw.Close()
 			return "", err
 		}
 	}
 
-	return outputPath, zw.Close()
+	return outputPath, fw.Close()
 }
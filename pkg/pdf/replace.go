@@ -6,7 +6,12 @@
 package pdf
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/unidoc/unipdf/v4/common"
 	"github.com/unidoc/unipdf/v4/contentstream"
@@ -20,14 +25,22 @@ type textChunk struct {
 	strObj *core.PdfObjectString
 	val    string
 	idx    int
+
+	// op is the text showing operation the chunk belongs to.
+	op *contentstream.ContentStreamOperation
+
+	// changed specifies if the value of the chunk was changed.
+	changed bool
 }
 
-func (tc *textChunk) encode() {
-	var encoded string
-	if font := tc.font; font != nil {
+// encode encodes the value of the chunk using the specified font. If no
+// font is specified, the value is used as is.
+func (tc *textChunk) encode(font *model.PdfFont) {
+	encoded := tc.val
+	if font != nil {
 		encodedBytes, numMisses := font.StringToCharcodeBytes(tc.val)
 		if numMisses != 0 {
-			common.Log.Debug("WARN: some runes could not be encoded.\n\t%s -> %v")
+			common.Log.Debug("WARN: some runes could not be encoded.\n\t%s -> %v", tc.val, encodedBytes)
 		}
 		encoded = string(encodedBytes)
 	}
@@ -35,220 +48,873 @@ func (tc *textChunk) encode() {
 	*tc.strObj = *core.MakeString(encoded)
 }
 
-type textChunks struct {
-	text   string
-	chunks []*textChunk
-}
+// unencodableRunes returns the distinct runes of the provided text which
+// cannot be drawn using the specified font. A rune cannot be drawn if it
+// cannot be encoded or if the font does not contain its glyph, which is
+// often the case for font subsets.
+func unencodableRunes(font *model.PdfFont, text string) []rune {
+	var runes []rune
+	seen := map[rune]bool{}
+	for _, r := range text {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
 
-func (tc *textChunks) replace(search, replacement string) {
-	text := tc.text
-	chunks := tc.chunks
-
-	// Steps:
-	// 1. Search for the first index of the search term in the text.
-	// 2. Use the found index to match the text chunk which contains
-	//    (or partly contains) the search term.
-	// 3. Replace the search term in the found text chunk. The search term
-	//    will not always start at the beginning of the text chunk. Also,
-	//    the search term could be split in multiple text chunks. If that's
-	//    the case, replace the portion of the search term in the found
-	//    chunk and continue removing characters from the following chunks
-	//    until the search term has been completely erased.
-	// 4. Offset the text chunks slice to the last processed text chunk from
-	//    the previous step, if the text chunk was not completely erased, or
-	//    to the next one otherwise. This is necessary so that the visited
-	//    text chunks are skipped when searching for the next occurrence of the
-	//    search term.
-	// 5. Discard the part of the text up to (and including) the index found
-	//    in step one.
-	// 6. Move to step 1 in order to search for the search term in the remaining
-	//    text.
-	var chunkOffset int
-	matchIdx := strings.Index(text, search)
-	for currMatchIdx := matchIdx; matchIdx != -1; {
-		for i, chunk := range chunks[chunkOffset:] {
-			idx, lenChunk := chunk.idx, len(chunk.val)
-			if currMatchIdx < idx || currMatchIdx > idx+lenChunk-1 {
+		if _, numMisses := font.StringToCharcodeBytes(string(r)); numMisses == 0 {
+			if metrics, ok := font.GetRuneMetrics(r); (ok && metrics.Wx > 0) || unicode.IsSpace(r) {
 				continue
 			}
-			chunkOffset += i + 1
+		}
 
-			start := currMatchIdx - idx
-			remaining := len(search) - (lenChunk - start)
+		runes = append(runes, r)
+	}
 
-			replaceVal := chunk.val[:start] + replacement
-			if remaining < 0 {
-				replaceVal += chunk.val[lenChunk+remaining:]
-				chunkOffset--
-			}
+	return runes
+}
 
-			chunk.val = replaceVal
-			chunk.encode()
+type textChunks struct {
+	text   string
+	chunks []*textChunk
+}
 
-			for j := chunkOffset; remaining > 0; j++ {
-				c := chunks[j]
-				l := len(c.val)
+// textMatch represents an occurrence of a search term in the text of a page,
+// along with the text replacing it.
+type textMatch struct {
+	offset      int
+	length      int
+	replacement string
 
-				if l > remaining {
-					c.val = c.val[remaining:]
-				} else {
-					c.val = ""
-					chunkOffset++
-				}
+	// replacer is the index of the replacer which found the match.
+	replacer int
+}
+
+// replace replaces the provided matches, which must be sorted by offset and
+// must not overlap. The replacement text of each match is inserted into the
+// text chunk containing the start of the match. If a match spans multiple
+// text chunks, the matched text is removed from the following chunks.
+// The changed chunks must be encoded afterwards.
+func (tc *textChunks) replace(matches []*textMatch) {
+	var m int
+	for _, chunk := range tc.chunks {
+		val := chunk.val
+		start, end := chunk.idx, chunk.idx+len(val)
+
+		// Skip the matches located before the chunk.
+		for m < len(matches) && matches[m].offset+matches[m].length <= start {
+			m++
+		}
+		if m == len(matches) {
+			break
+		}
 
-				c.encode()
-				remaining -= l
+		var sb strings.Builder
+		var changed bool
+		pos := start
+		for i := m; i < len(matches) && matches[i].offset < end; i++ {
+			match := matches[i]
+			if match.offset >= start {
+				sb.WriteString(val[pos-start : match.offset-start])
+				sb.WriteString(match.replacement)
 			}
 
-			break
+			pos = min(match.offset+match.length, end)
+			changed = true
 		}
+		if !changed {
+			continue
+		}
+		sb.WriteString(val[pos-start:])
+
+		chunk.val = sb.String()
+		chunk.changed = true
+	}
 
-		text = text[matchIdx+1:]
-		matchIdx = strings.Index(text, search)
-		currMatchIdx += matchIdx + 1
+	// Update the text of the chunks.
+	var sb strings.Builder
+	for _, chunk := range tc.chunks {
+		chunk.idx = sb.Len()
+		sb.WriteString(chunk.val)
 	}
+	tc.text = sb.String()
+}
 
-	tc.text = strings.Replace(tc.text, search, replacement, -1)
+// ReplacePair represents a search term and the text replacing it.
+type ReplacePair struct {
+	// Search is the searched text. If regular expressions are enabled, the
+	// searched text is a regular expression.
+	Search string
+
+	// Replacement is the text replacing the occurrences of the search term.
+	// If regular expressions are enabled, the replacement text can reference
+	// the capture groups of the search term (e.g. $1 or ${name}).
+	Replacement string
+}
+
+// Replacement scopes, specifying the parts of a document in which text is
+// replaced.
+const (
+	// ReplaceScopeContent represents the text drawn by the content streams
+	// of the pages.
+	ReplaceScopeContent = "content"
+
+	// ReplaceScopeAnnotations represents the text of the annotations of the
+	// pages (e.g. comments and free text annotations).
+	ReplaceScopeAnnotations = "annotations"
+
+	// ReplaceScopeForms represents the values of the form fields.
+	ReplaceScopeForms = "forms"
+
+	// ReplaceScopeOutline represents the titles of the outline items
+	// (bookmarks).
+	ReplaceScopeOutline = "outline"
+
+	// ReplaceScopeMetadata represents the document information dictionary
+	// and the XMP metadata.
+	ReplaceScopeMetadata = "metadata"
+)
+
+// ReplaceOpts represents the options used for replacing text.
+type ReplaceOpts struct {
+	// Regex specifies if the searched texts are regular expressions.
+	Regex bool
+
+	// IgnoreCase specifies if the search is case insensitive.
+	IgnoreCase bool
+
+	// Pages contains the numbers of the pages on which the text is replaced.
+	// If empty, the text is replaced on all the pages.
+	Pages []int
+
+	// DryRun specifies if the replacements are only counted, without
+	// writing the output file.
+	DryRun bool
+
+	// FallbackFontPath is the path of a TrueType font file used for drawing
+	// the replaced text containing characters which cannot be drawn using
+	// the original fonts (e.g. characters missing from font subsets).
+	FallbackFontPath string
+
+	// Scopes contains the parts of the document in which text is replaced
+	// (see the ReplaceScope constants). If empty, text is only replaced in
+	// the content streams of the pages. The Pages option applies to the
+	// content and annotations scopes.
+	Scopes []string
+}
+
+// ReplaceResult contains the number of replacements made on a page or in
+// a part of the document.
+type ReplaceResult struct {
+	// The number of the page. The page number is 0 for the scopes which
+	// are not page specific (forms, outline and metadata).
+	Page int
+
+	// The scope in which the replacements were made.
+	Scope string
+
+	// The number of replacements made on the page.
+	Replacements int
+
+	// The characters of the replaced text which cannot be drawn using the
+	// fonts of the page or the fallback font, if specified.
+	UnencodableRunes []rune
 }
 
 // Replace searches the provided text in the PDF file specified by the inputPath
 // parameter and replaces it by the newText. A password can be passed in for encrypted input files.
 // The result is saved to outputPath.
 func Replace(inputPath, outputPath, text, replaceText, password string) error {
+	pairs := []*ReplacePair{{Search: text, Replacement: replaceText}}
+	_, err := ReplaceAll(inputPath, outputPath, password, pairs, nil)
+	return err
+}
+
+// ReplaceAll replaces the occurrences of the search terms of the provided
+// pairs in the PDF file specified by the inputPath parameter, in a single
+// pass. If the occurrences of multiple search terms overlap, the leftmost
+// occurrence is replaced. Occurrences starting at the same position are
+// replaced using the first matching pair. The replacement texts are not
+// searched for other terms.
+// The replacements can be configured using the opts parameter. If the opts
+// parameter is nil, the search terms are searched literally on all pages.
+// A password can be passed in for encrypted input files. The result is saved
+// to outputPath, unless a dry run is requested.
+// Replaced text containing characters which cannot be drawn using the
+// original fonts (e.g. characters missing from font subsets) is drawn using
+// the fallback font, if specified.
+// By default, text is only replaced in the content streams of the pages.
+// Other parts of the document, such as annotations, form field values,
+// bookmarks and metadata, can be included using the Scopes option.
+// The function returns the number of replacements made on each page and in
+// each scope which contains at least one occurrence of the search terms,
+// along with the characters which cannot be drawn.
+func ReplaceAll(inputPath, outputPath, password string, pairs []*ReplacePair, opts *ReplaceOpts) ([]*ReplaceResult, error) {
+	if opts == nil {
+		opts = &ReplaceOpts{}
+	}
+
+	scopes := map[string]bool{}
+	for _, scope := range opts.Scopes {
+		switch scope {
+		case ReplaceScopeContent, ReplaceScopeAnnotations, ReplaceScopeForms,
+			ReplaceScopeOutline, ReplaceScopeMetadata:
+			scopes[scope] = true
+		default:
+			return nil, fmt.Errorf("unsupported replacement scope: %s", scope)
+		}
+	}
+	if len(scopes) == 0 {
+		scopes[ReplaceScopeContent] = true
+	}
+
+	// Compile search terms. The terms searched in XMP metadata are XML
+	// escaped.
+	replacers := make([]*textReplacer, 0, len(pairs))
+	xmpReplacers := make([]*textReplacer, 0, len(pairs))
+	for _, pair := range pairs {
+		replacer, err := newTextReplacer(pair, opts)
+		if err != nil {
+			return nil, err
+		}
+		replacers = append(replacers, replacer)
+
+		xmpPair := &ReplacePair{Search: pair.Search, Replacement: xmlEscape(pair.Replacement)}
+		if !opts.Regex {
+			xmpPair.Search = xmlEscape(pair.Search)
+		}
+		if replacer, err = newTextReplacer(xmpPair, opts); err != nil {
+			return nil, err
+		}
+		xmpReplacers = append(xmpReplacers, replacer)
+	}
+
+	// Load fallback font.
+	var fallback *unipdf.PdfFont
+	if opts.FallbackFontPath != "" {
+		font, err := unipdf.NewCompositePdfFontFromTTFFile(opts.FallbackFontPath)
+		if err != nil {
+			return nil, err
+		}
+		fallback = font
+	}
+
 	// Read input file.
-	r, pages, _, _, err := readPDF(inputPath, password)
+	r, pageCount, _, _, err := readPDF(inputPath, password)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	pages := map[int]bool{}
+	for _, numPage := range opts.Pages {
+		pages[numPage] = true
 	}
 
 	w := unipdf.NewPdfWriter()
 
-	// Search specified text.
-	for i := 0; i < pages; i++ {
+	// Replace specified text.
+	var results []*ReplaceResult
+	for i := 0; i < pageCount; i++ {
 		// Get page.
 		numPage := i + 1
 
 		page, err := r.GetPage(numPage)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		err = searchReplacePageText(page, text, replaceText)
-		if err != nil {
-			return err
+		if len(pages) == 0 || pages[numPage] {
+			if scopes[ReplaceScopeContent] {
+				count, missing, err := searchReplacePageText(page, replacers, fallback, opts.DryRun)
+				if err != nil {
+					return nil, err
+				}
+				if count > 0 {
+					results = append(results, &ReplaceResult{
+						Page:             numPage,
+						Scope:            ReplaceScopeContent,
+						Replacements:     count,
+						UnencodableRunes: missing,
+					})
+				}
+			}
+
+			if scopes[ReplaceScopeAnnotations] {
+				count, err := replacePageAnnotations(page, replacers)
+				if err != nil {
+					return nil, err
+				}
+				if count > 0 {
+					results = append(results, &ReplaceResult{
+						Page:         numPage,
+						Scope:        ReplaceScopeAnnotations,
+						Replacements: count,
+					})
+				}
+			}
 		}
 
-		err = w.AddPage(page)
-		if err != nil {
-			return err
+		if err = w.AddPage(page); err != nil {
+			return nil, err
+		}
+	}
+
+	// Replace text in the document level scopes. The forms, outline and
+	// metadata are copied to the output file, even if they are not in scope.
+	addResult := func(scope string, count int) {
+		if count > 0 {
+			results = append(results, &ReplaceResult{Scope: scope, Replacements: count})
+		}
+	}
+
+	if r.AcroForm != nil {
+		if scopes[ReplaceScopeForms] {
+			addResult(ReplaceScopeForms, replaceFormValues(r.AcroForm, replacers))
 		}
+		if err := w.SetForms(r.AcroForm); err != nil {
+			return nil, err
+		}
+	}
+
+	outline := r.GetOutlineTree()
+	if scopes[ReplaceScopeOutline] {
+		addResult(ReplaceScopeOutline, replaceOutlineTitles(outline, replacers))
+	}
+	w.AddOutlineTree(outline)
+
+	if !scopes[ReplaceScopeMetadata] {
+		replacers, xmpReplacers = nil, nil
+	}
+	count, err := replaceMetadata(r, &w, replacers, xmpReplacers)
+	if err != nil {
+		return nil, err
+	}
+	addResult(ReplaceScopeMetadata, count)
+
+	if opts.DryRun {
+		return results, nil
 	}
 
 	// Write output file.
 	safe := inputPath == outputPath
-	return writePDF(outputPath, &w, safe)
+	if err := writePDF(outputPath, &w, safe); err != nil {
+		return nil, err
+	}
+
+	return results, nil
 }
 
-func searchReplacePageText(page *model.PdfPage, searchText, replaceText string) error {
-	contents, err := page.GetAllContentStreams()
+// textReplacer finds the occurrences of a search term in text and generates
+// their replacements.
+type textReplacer struct {
+	re          *regexp.Regexp
+	replacement string
+	expand      bool
+
+	// accept, if specified, filters the occurrences of the search term,
+	// identified by their start and end offsets in the searched text.
+	accept func(text string, start, end int) bool
+}
+
+func newTextReplacer(pair *ReplacePair, opts *ReplaceOpts) (*textReplacer, error) {
+	pattern := pair.Search
+	if !opts.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return &textReplacer{
+		re:          re,
+		replacement: pair.Replacement,
+		expand:      opts.Regex,
+	}, nil
+}
+
+// find returns the locations of the occurrences of the search term in the
+// provided text, along with the locations of their capture groups.
+// If the occurrences are filtered, the search is resumed after the start of
+// each rejected occurrence, so that overlapping occurrences are not missed.
+func (r *textReplacer) find(text string) [][]int {
+	if r.accept == nil {
+		return r.re.FindAllStringSubmatchIndex(text, -1)
 	}
 
-	ops, err := contentstream.NewContentStreamParser(contents).Parse()
+	var locs [][]int
+	for pos := 0; pos < len(text); {
+		loc := r.re.FindStringSubmatchIndex(text[pos:])
+		if loc == nil {
+			break
+		}
+		for i := range loc {
+			if loc[i] >= 0 {
+				loc[i] += pos
+			}
+		}
+
+		if loc[0] != loc[1] && r.accept(text, loc[0], loc[1]) {
+			locs = append(locs, loc)
+			pos = loc[1]
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(text[loc[0]:])
+		pos = loc[0] + size
+	}
+
+	return locs
+}
+
+// findMatches returns the non-overlapping occurrences of the search terms of
+// the specified replacers in the provided text, sorted by offset.
+func findMatches(text string, replacers []*textReplacer) []*textMatch {
+	var candidates []*textMatch
+	for i, replacer := range replacers {
+		for _, loc := range replacer.find(text) {
+			if loc[0] == loc[1] {
+				continue
+			}
+
+			replacement := replacer.replacement
+			if replacer.expand {
+				replacement = string(replacer.re.ExpandString(nil, replacement, text, loc))
+			}
+
+			candidates = append(candidates, &textMatch{
+				offset:      loc[0],
+				length:      loc[1] - loc[0],
+				replacement: replacement,
+				replacer:    i,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].offset != candidates[j].offset {
+			return candidates[i].offset < candidates[j].offset
+		}
+		return candidates[i].replacer < candidates[j].replacer
+	})
+
+	var matches []*textMatch
+	end := 0
+	for _, c := range candidates {
+		if c.offset < end {
+			continue
+		}
+
+		matches = append(matches, c)
+		end = c.offset + c.length
+	}
+
+	return matches
+}
+
+// opFont represents the font used by a text showing operation.
+type opFont struct {
+	name core.PdfObjectName
+	size float64
+}
+
+// searchReplacePageText replaces the occurrences of the search terms of the
+// specified replacers in the content streams of the provided page. If the
+// dryRun parameter is true, the page is not modified.
+// The text showing operations containing characters which cannot be drawn
+// using their original fonts are switched to the specified fallback font.
+// If no fallback font is specified, or if the fallback font cannot draw the
+// characters either, the characters are returned, along with the number of
+// replacements.
+func searchReplacePageText(page *model.PdfPage, replacers []*textReplacer, fallback *model.PdfFont,
+	dryRun bool) (int, []rune, error) {
+	contents, err := page.GetAllContentStreams()
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 
 	// Generate text chunks.
-	var currFont *model.PdfFont
+	opFonts := map[*contentstream.ContentStreamOperation]*opFont{}
 	tc := textChunks{}
 
-	textProcFunc := func(objptr *core.PdfObject) {
-		strObj, ok := core.GetString(*objptr)
+	walker := &contentWalker{
+		showText: func(op *contentstream.ContentStreamOperation, _ int, strObj *core.PdfObjectString,
+			_ []*textGlyph, state *contentState) {
+			font := state.text.font
+
+			str := strObj.String()
+			if font != nil {
+				decoded, _, numMisses := font.CharcodeBytesToUnicode(strObj.Bytes())
+				if numMisses != 0 {
+					common.Log.Debug("WARN: some charcodes could not be decoded.\n\t%v -> %s", strObj.Bytes(), decoded)
+				}
+				str = decoded
+			}
+
+			tc.chunks = append(tc.chunks, &textChunk{
+				font:   font,
+				strObj: strObj,
+				val:    str,
+				idx:    len(tc.text),
+				op:     op,
+			})
+			tc.text += str
+
+			if font != nil {
+				opFonts[op] = &opFont{name: state.text.fontName, size: state.text.size}
+			}
+		},
+	}
+
+	content, err := walker.walk(contents, page.Resources)
+	if err != nil {
+		return 0, nil, err
+	}
+	ops := content.ops
+
+	matches := findMatches(tc.text, replacers)
+	if len(matches) == 0 {
+		return 0, nil, nil
+	}
+	tc.replace(matches)
+
+	// Find the text showing operations which cannot be drawn using their
+	// original fonts.
+	missing := map[rune]bool{}
+	fallbackOps := map[*contentstream.ContentStreamOperation]bool{}
+	for _, chunk := range tc.chunks {
+		if !chunk.changed || chunk.font == nil {
+			continue
+		}
+
+		runes := unencodableRunes(chunk.font, chunk.val)
+		if len(runes) == 0 {
+			continue
+		}
+		if fallback != nil && opFonts[chunk.op] != nil {
+			fallbackOps[chunk.op] = true
+			continue
+		}
+
+		for _, r := range runes {
+			missing[r] = true
+		}
+	}
+
+	// Encode the changed chunks. All the chunks of the operations switched
+	// to the fallback font are encoded using the fallback font.
+	for _, chunk := range tc.chunks {
+		switch {
+		case fallbackOps[chunk.op]:
+			for _, r := range unencodableRunes(fallback, chunk.val) {
+				missing[r] = true
+			}
+			chunk.encode(fallback)
+		case chunk.changed:
+			chunk.encode(chunk.font)
+		}
+	}
+
+	var missingRunes []rune
+	for r := range missing {
+		missingRunes = append(missingRunes, r)
+	}
+	sort.Slice(missingRunes, func(i, j int) bool { return missingRunes[i] < missingRunes[j] })
+
+	if dryRun {
+		return len(matches), missingRunes, nil
+	}
+
+	// Switch the font of the operations to the fallback font, restoring the
+	// original font afterwards.
+	if len(fallbackOps) > 0 {
+		if page.Resources == nil {
+			page.Resources = model.NewPdfPageResources()
+		}
+
+		name := resourceName("FFallback", page.Resources.HasFontByName)
+		if err := page.Resources.SetFontByName(name, fallback.ToPdfObject()); err != nil {
+			return 0, nil, err
+		}
+
+		newOps := make(contentstream.ContentStreamOperations, 0, len(*ops)+2*len(fallbackOps))
+		for _, op := range *ops {
+			if !fallbackOps[op] {
+				newOps = append(newOps, op)
+				continue
+			}
+
+			font := opFonts[op]
+			newOps = append(newOps,
+				&contentstream.ContentStreamOperation{
+					Operand: "Tf",
+					Params:  []core.PdfObject{core.MakeName(string(name)), core.MakeFloat(font.size)},
+				},
+				op,
+				&contentstream.ContentStreamOperation{
+					Operand: "Tf",
+					Params:  []core.PdfObject{core.MakeName(string(font.name)), core.MakeFloat(font.size)},
+				},
+			)
+		}
+		*ops = newOps
+	}
+
+	if err := page.SetContentStreams([]string{ops.String()}, core.NewFlateEncoder()); err != nil {
+		return 0, nil, err
+	}
+
+	return len(matches), missingRunes, nil
+}
+
+// replaceText replaces the matches of the specified replacers in the
+// provided text. Returns the number of replacements.
+func replaceText(text string, replacers []*textReplacer) (string, int) {
+	matches := findMatches(text, replacers)
+	if len(matches) == 0 {
+		return text, 0
+	}
+
+	var b strings.Builder
+	var pos int
+	for _, match := range matches {
+		b.WriteString(text[pos:match.offset])
+		b.WriteString(match.replacement)
+		pos = match.offset + match.length
+	}
+	b.WriteString(text[pos:])
+
+	return b.String(), len(matches)
+}
+
+// replaceStringObject replaces the matches of the specified replacers in
+// the provided string object. Returns the number of replacements. Objects
+// which are not strings are not changed.
+func replaceStringObject(obj *core.PdfObject, replacers []*textReplacer) int {
+	str, ok := core.GetString(*obj)
+	if !ok {
+		return 0
+	}
+
+	text, count := replaceText(str.Decoded(), replacers)
+	if count > 0 {
+		*obj = core.MakeEncodedString(text, true)
+	}
+
+	return count
+}
+
+// xmlEscape escapes the special XML characters of the provided text.
+func xmlEscape(text string) string {
+	return strings.NewReplacer(
+		"&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;",
+	).Replace(text)
+}
+
+// replaceAnnotationText replaces the matches of the specified replacers in
+// the text entries (contents, author, subject and rich text) of the
+// provided annotation. The appearance stream of free text annotations, which
+// displays their contents, is removed if their text is changed, so that
+// viewers regenerate it using the new text. The appearances of the other
+// annotations do not display their text and are kept. Returns the number of
+// replacements.
+func replaceAnnotationText(annot *model.PdfAnnotation, dict *core.PdfObjectDictionary, replacers []*textReplacer) int {
+	var count int
+	replace := func(key core.PdfObjectName, field *core.PdfObject) {
+		if n := replaceStringObject(field, replacers); n > 0 {
+			dict.Set(key, *field)
+			count += n
+		}
+	}
+
+	replace("Contents", &annot.Contents)
+	if markup := annotationMarkup(annot); markup != nil {
+		replace("T", &markup.T)
+		replace("Subj", &markup.Subj)
+		replace("RC", &markup.RC)
+	}
+
+	if subtype, _ := core.GetNameVal(dict.Get("Subtype")); subtype == "FreeText" && count > 0 {
+		annot.AP = nil
+		dict.Remove("AP")
+	}
+
+	return count
+}
+
+// replacePageAnnotations replaces the matches of the specified replacers in
+// the text of the annotations of the provided page. Widget (form field)
+// annotations are skipped. Returns the number of replacements.
+func replacePageAnnotations(page *model.PdfPage, replacers []*textReplacer) (int, error) {
+	annots, err := page.GetAnnotations()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, annot := range annots {
+		dict, ok := core.GetDict(annot.GetContainingPdfObject())
 		if !ok {
-			common.Log.Debug("Invalid parameter, skipping")
-			return
+			continue
 		}
+		if subtype, _ := core.GetNameVal(dict.Get("Subtype")); subtype == "Widget" {
+			continue
+		}
+
+		count += replaceAnnotationText(annot, dict, replacers)
+	}
 
-		str := strObj.String()
-		if currFont != nil {
-			decoded, _, numMisses := currFont.CharcodeBytesToUnicode(strObj.Bytes())
-			if numMisses != 0 {
-				common.Log.Debug("WARN: some charcodes could not be decoded.\n\t%v -> %s", strObj.Bytes(), decoded)
+	return count, nil
+}
+
+// replaceFormValues replaces the matches of the specified replacers in the
+// values of the fields of the provided form. The form is marked as needing
+// new appearances if any values are changed, so that viewers display the
+// new values. Returns the number of replacements.
+func replaceFormValues(form *model.PdfAcroForm, replacers []*textReplacer) int {
+	if form == nil {
+		return 0
+	}
+
+	var count int
+	for _, field := range form.AllFields() {
+		count += replaceFieldValue(field, replacers)
+	}
+
+	if count > 0 {
+		form.NeedAppearances = core.MakeBool(true)
+	}
+
+	return count
+}
+
+// replaceFieldValue replaces the matches of the specified replacers in the
+// value and the rich text value of the provided form field. Returns the
+// number of replacements.
+func replaceFieldValue(field *model.PdfField, replacers []*textReplacer) int {
+	var count int
+
+	// The values of choice fields allowing multiple selections are arrays
+	// of strings.
+	if arr, ok := core.GetArray(field.V); ok {
+		for i, elem := range arr.Elements() {
+			if n := replaceStringObject(&elem, replacers); n > 0 {
+				arr.Set(i, elem)
+				count += n
 			}
-			str = decoded
 		}
+	} else {
+		count += replaceStringObject(&field.V, replacers)
+	}
 
-		tc.chunks = append(tc.chunks, &textChunk{
-			font:   currFont,
-			strObj: strObj,
-			val:    str,
-			idx:    len(tc.text),
-		})
-		tc.text += str
+	if dict, ok := core.GetDict(field.GetContainingPdfObject()); ok {
+		rv := dict.Get("RV")
+		if n := replaceStringObject(&rv, replacers); n > 0 {
+			dict.Set("RV", rv)
+			count += n
+		}
 	}
 
-	processor := contentstream.NewContentStreamProcessor(*ops)
-	processor.AddHandler(contentstream.HandlerConditionEnumAllOperands, "",
-		func(op *contentstream.ContentStreamOperation, _ contentstream.GraphicsState, resources *model.PdfPageResources) error {
-			switch op.Operand {
-			case `Tj`, `'`:
-				if len(op.Params) != 1 {
-					common.Log.Debug("Invalid: Tj/' with invalid set of parameters - skip")
-					return nil
-				}
-				textProcFunc(&op.Params[0])
-			case `''`:
-				if len(op.Params) != 3 {
-					common.Log.Debug("Invalid: '' with invalid set of parameters - skip")
-					return nil
-				}
-				textProcFunc(&op.Params[3])
-			case `TJ`:
-				if len(op.Params) != 1 {
-					common.Log.Debug("Invalid: TJ with invalid set of parameters - skip")
-					return nil
-				}
-				arr, _ := core.GetArray(op.Params[0])
-				for i := range arr.Elements() {
-					obj := arr.Get(i)
-					textProcFunc(&obj)
-					arr.Set(i, obj)
-				}
-			case "Tf":
-				if len(op.Params) != 2 {
-					common.Log.Debug("Invalid: Tf with invalid set of parameters - skip")
-					return nil
-				}
+	return count
+}
 
-				fname, ok := core.GetName(op.Params[0])
-				if !ok || fname == nil {
-					common.Log.Debug("ERROR: could not get font name")
-					return nil
-				}
+// replaceOutlineTitles replaces the matches of the specified replacers in
+// the titles of the items of the provided outline (bookmarks) tree.
+// Returns the number of replacements.
+func replaceOutlineTitles(tree *model.PdfOutlineTreeNode, replacers []*textReplacer) int {
+	if tree == nil {
+		return 0
+	}
 
-				fObj, has := resources.GetFontByName(*fname)
-				if !has {
-					common.Log.Debug("ERROR: font %s not found", fname.String())
-					return nil
+	var count int
+	visited := map[*model.PdfOutlineItem]bool{}
+
+	var replace func(node *model.PdfOutlineTreeNode)
+	replace = func(node *model.PdfOutlineTreeNode) {
+		for node != nil {
+			item, ok := node.GetContext().(*model.PdfOutlineItem)
+			if !ok || visited[item] {
+				return
+			}
+			visited[item] = true
+
+			if item.Title != nil {
+				if text, n := replaceText(item.Title.Decoded(), replacers); n > 0 {
+					item.Title = core.MakeEncodedString(text, true)
+					count += n
 				}
+			}
 
-				pdfFont, err := model.NewPdfFontFromPdfObject(fObj)
-				if err != nil {
-					common.Log.Debug("ERROR: loading font")
-					return nil
+			replace(item.First)
+			node = item.Next
+		}
+	}
+	replace(tree.First)
+
+	return count
+}
+
+// replaceMetadata replaces the matches of the specified replacers in the
+// document information dictionary and the XMP metadata of the provided
+// reader, and sets them to the specified writer. The XMP metadata is
+// processed using the xmpReplacers, which must generate XML escaped text.
+// Returns the number of replacements.
+func replaceMetadata(r *model.PdfReader, w *model.PdfWriter, replacers, xmpReplacers []*textReplacer) (int, error) {
+	var count int
+
+	// Replace document information.
+	if info, err := r.GetPdfInfo(); err == nil && info != nil {
+		fields := []**core.PdfObjectString{
+			&info.Title, &info.Author, &info.Subject, &info.Keywords, &info.Creator, &info.Producer,
+		}
+		for _, field := range fields {
+			if *field == nil {
+				continue
+			}
+			if text, n := replaceText((*field).Decoded(), replacers); n > 0 {
+				*field = core.MakeEncodedString(text, true)
+				count += n
+			}
+		}
+
+		for _, key := range info.CustomKeys() {
+			val := info.GetCustomInfo(key)
+			if val == nil {
+				continue
+			}
+			if text, n := replaceText(val.Decoded(), replacers); n > 0 {
+				if err := info.SetCustomInfo(key, text); err != nil {
+					return 0, err
 				}
-				currFont = pdfFont
+				count += n
 			}
+		}
 
-			return nil
-		})
+		w.SetDocInfo(info)
+	}
 
-	if err = processor.Process(page.Resources); err != nil {
-		return err
+	// Replace XMP metadata.
+	meta, ok := r.GetCatalogMetadata()
+	if !ok {
+		return count, nil
+	}
+
+	if stream, ok := core.GetStream(meta); ok {
+		data, err := core.DecodeStream(stream)
+		if err != nil {
+			return 0, err
+		}
+
+		if text, n := replaceText(string(data), xmpReplacers); n > 0 {
+			if meta, err = copyStream(stream, []byte(text)); err != nil {
+				return 0, err
+			}
+			count += n
+		}
 	}
 
-	tc.replace(searchText, replaceText)
-	return page.SetContentStreams([]string{ops.String()}, core.NewFlateEncoder())
+	return count, w.SetCatalogMetadata(meta)
 }
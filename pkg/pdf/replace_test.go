@@ -0,0 +1,227 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// newTestReplacers returns text replacers for the provided search and
+// replacement pairs.
+func newTestReplacers(t *testing.T, opts *ReplaceOpts, pairs ...string) []*textReplacer {
+	t.Helper()
+
+	var replacers []*textReplacer
+	for i := 0; i+1 < len(pairs); i += 2 {
+		replacer, err := newTextReplacer(&ReplacePair{Search: pairs[i], Replacement: pairs[i+1]}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replacers = append(replacers, replacer)
+	}
+
+	return replacers
+}
+
+// formatMatches returns the provided matches in the offset:length:replacer
+// replacement format.
+func formatMatches(matches []*textMatch) []string {
+	var strs []string
+	for _, m := range matches {
+		strs = append(strs, fmt.Sprintf("%d:%d:%d %s", m.offset, m.length, m.replacer, m.replacement))
+	}
+
+	return strs
+}
+
+func TestFindMatches(t *testing.T) {
+	testCases := []struct {
+		name  string
+		text  string
+		opts  *ReplaceOpts
+		pairs []string
+		want  []string
+	}{
+		{
+			name:  "literal",
+			text:  "a.b axb a.b",
+			opts:  &ReplaceOpts{},
+			pairs: []string{"a.b", "$1"},
+			want:  []string{"0:3:0 $1", "8:3:0 $1"},
+		},
+		{
+			name:  "ignore case",
+			text:  "Foo foo FOO",
+			opts:  &ReplaceOpts{IgnoreCase: true},
+			pairs: []string{"foo", "bar"},
+			want:  []string{"0:3:0 bar", "4:3:0 bar", "8:3:0 bar"},
+		},
+		{
+			name:  "overlap keeps leftmost match",
+			text:  "abcdef",
+			opts:  &ReplaceOpts{},
+			pairs: []string{"cde", "Y", "bcd", "X"},
+			want:  []string{"1:3:1 X"},
+		},
+		{
+			name:  "overlap at same offset keeps first replacer",
+			text:  "abcdef",
+			opts:  &ReplaceOpts{},
+			pairs: []string{"ab", "X", "abcd", "Y"},
+			want:  []string{"0:2:0 X"},
+		},
+		{
+			name:  "matches sorted by offset",
+			text:  "one two one two",
+			opts:  &ReplaceOpts{},
+			pairs: []string{"two", "2", "one", "1"},
+			want:  []string{"0:3:1 1", "4:3:0 2", "8:3:1 1", "12:3:0 2"},
+		},
+		{
+			name:  "regex expansion",
+			text:  "mail bob@example.com or amy@example.com",
+			opts:  &ReplaceOpts{Regex: true},
+			pairs: []string{`(\w+)@example\.com`, "$1 at example"},
+			want:  []string{"5:15:0 bob at example", "24:15:0 amy at example"},
+		},
+		{
+			name:  "regex named group expansion",
+			text:  "2024-05-17",
+			opts:  &ReplaceOpts{Regex: true},
+			pairs: []string{`(?P<y>\d{4})-(?P<m>\d{2})-(?P<d>\d{2})`, "${d}/${m}/${y}"},
+			want:  []string{"0:10:0 17/05/2024"},
+		},
+		{
+			name:  "empty matches are skipped",
+			text:  "abc",
+			opts:  &ReplaceOpts{Regex: true},
+			pairs: []string{`x*`, "y"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			replacers := newTestReplacers(t, tc.opts, tc.pairs...)
+
+			got := formatMatches(findMatches(tc.text, replacers))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTextChunksReplace(t *testing.T) {
+	testCases := []struct {
+		name        string
+		chunks      []string
+		pairs       []string
+		wantChunks  []string
+		wantChanged []bool
+	}{
+		{
+			name:        "single chunk",
+			chunks:      []string{"Hello World"},
+			pairs:       []string{"World", "There"},
+			wantChunks:  []string{"Hello There"},
+			wantChanged: []bool{true},
+		},
+		{
+			name:        "multiple matches in chunk",
+			chunks:      []string{"a-b-a", "b"},
+			pairs:       []string{"a", "xy"},
+			wantChunks:  []string{"xy-b-xy", "b"},
+			wantChanged: []bool{true, false},
+		},
+		{
+			name:        "match spanning two chunks",
+			chunks:      []string{"Hello Wo", "rld!"},
+			pairs:       []string{"World", "Earth"},
+			wantChunks:  []string{"Hello Earth", "!"},
+			wantChanged: []bool{true, true},
+		},
+		{
+			name:        "match spanning three chunks",
+			chunks:      []string{"ab", "cd", "ef"},
+			pairs:       []string{"bcde", "X"},
+			wantChunks:  []string{"aX", "", "f"},
+			wantChanged: []bool{true, true, true},
+		},
+		{
+			name:        "match starting at chunk boundary",
+			chunks:      []string{"abc", "def", "ghi"},
+			pairs:       []string{"def", "X"},
+			wantChunks:  []string{"abc", "X", "ghi"},
+			wantChanged: []bool{false, true, false},
+		},
+		{
+			name:        "matches spanning and inside chunks",
+			chunks:      []string{"one tw", "o three", " two"},
+			pairs:       []string{"two", "2"},
+			wantChunks:  []string{"one 2", " three", " 2"},
+			wantChanged: []bool{true, true, true},
+		},
+		{
+			name:        "no matches",
+			chunks:      []string{"abc", "def"},
+			pairs:       []string{"xyz", "X"},
+			wantChunks:  []string{"abc", "def"},
+			wantChanged: []bool{false, false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks := textChunks{}
+			for _, val := range tc.chunks {
+				chunks.chunks = append(chunks.chunks, &textChunk{val: val, idx: len(chunks.text)})
+				chunks.text += val
+			}
+
+			replacers := newTestReplacers(t, &ReplaceOpts{}, tc.pairs...)
+			chunks.replace(findMatches(chunks.text, replacers))
+
+			var text, wantText string
+			var gotVals []string
+			var gotChanged []bool
+			for _, chunk := range chunks.chunks {
+				if chunk.idx != len(text) {
+					t.Errorf("chunk %q has offset %d, want %d", chunk.val, chunk.idx, len(text))
+				}
+				text += chunk.val
+				gotVals = append(gotVals, chunk.val)
+				gotChanged = append(gotChanged, chunk.changed)
+			}
+			for _, val := range tc.wantChunks {
+				wantText += val
+			}
+
+			if !reflect.DeepEqual(gotVals, tc.wantChunks) {
+				t.Errorf("got chunks %q, want %q", gotVals, tc.wantChunks)
+			}
+			if !reflect.DeepEqual(gotChanged, tc.wantChanged) {
+				t.Errorf("got changed %v, want %v", gotChanged, tc.wantChanged)
+			}
+			if chunks.text != wantText {
+				t.Errorf("got text %q, want %q", chunks.text, wantText)
+			}
+		})
+	}
+}
+
+func TestReplaceText(t *testing.T) {
+	replacers := newTestReplacers(t, &ReplaceOpts{Regex: true}, `(\d+) USD`, "$$$1", "secret", "***")
+
+	text, count := replaceText("  paid 100 USD for the secret  ", replacers)
+	if want := "  paid $100 for the ***  "; text != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+	if count != 2 {
+		t.Errorf("got %d replacements, want 2", count)
+	}
+}
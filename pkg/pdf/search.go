@@ -6,23 +6,194 @@
 package pdf
 
 import (
+	"math"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
-
-	uniextractor "github.com/unidoc/unipdf/v4/extractor"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 )
 
+// SearchOpts represents the options used for searching text.
+type SearchOpts struct {
+	// Regex specifies if the searched text is a regular expression.
+	Regex bool
+
+	// IgnoreCase specifies if the search is case insensitive.
+	IgnoreCase bool
+
+	// WholeWord specifies if only whole words are matched.
+	WholeWord bool
+
+	// NormalizeWhitespace specifies if consecutive whitespace characters
+	// (including line breaks) are collapsed into a single space and
+	// hyphenated line breaks are removed before searching. This allows
+	// matching text which spans multiple lines.
+	NormalizeWhitespace bool
+
+	// Context is the number of characters of surrounding text included in
+	// the snippet of each match. If 0, no snippets are generated.
+	Context int
+}
+
+// SearchMatch represents an occurrence of the searched text.
+type SearchMatch struct {
+	// Text is the matched text. If whitespace normalization is enabled,
+	// the matched text is normalized.
+	Text string `json:"text"`
+
+	// Offset and Length represent the position of the match in the text
+	// of the page, in bytes.
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+
+	// BBoxes contains the bounding boxes of the match, in PDF coordinates.
+	// Matches spanning multiple lines have a bounding box for each line.
+	BBoxes []Rect `json:"bboxes"`
+
+	// Snippet contains the match, along with its surrounding text.
+	Snippet string `json:"snippet,omitempty"`
+}
+
 // SearchResult contains information about a found search term inside a PDF page.
 type SearchResult struct {
 	// The page the search term was found on.
-	Page int
+	Page int `json:"page"`
 
 	// The number of occurrences of the search term inside the page.
-	Occurrences int
+	Occurrences int `json:"occurrences"`
+
+	// The occurrences of the search term inside the page.
+	Matches []*SearchMatch `json:"matches"`
+}
+
+// FileSearchResult contains the search results of a PDF file.
+type FileSearchResult struct {
+	// The path of the searched file.
+	File string `json:"file"`
+
+	// The number of occurrences of the search term inside the file.
+	Occurrences int `json:"occurrences"`
+
+	// The search results of the pages containing the search term.
+	Results []*SearchResult `json:"results,omitempty"`
+
+	// The error encountered while searching the file, if any.
+	Error string `json:"error,omitempty"`
 }
 
 // Search searches the provided text in the PDF file specified by the inputPath
 // parameter. A password can be passed in for encrypted input files.
 func Search(inputPath, text, password string) ([]*SearchResult, error) {
+	return SearchWithOpts(inputPath, text, password, nil)
+}
+
+// SearchWithOpts searches the provided text in the PDF file specified by the
+// inputPath parameter. A password can be passed in for encrypted input files.
+// In addition, the search can be configured using the opts parameter.
+// If the opts parameter is nil, the provided text is searched literally.
+func SearchWithOpts(inputPath, text, password string, opts *SearchOpts) ([]*SearchResult, error) {
+	s, err := newSearcher(text, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.searchFile(inputPath, password)
+}
+
+// SearchFiles searches the provided text in the PDF files specified by the
+// inputPaths parameter. The files are searched in parallel, using the number
+// of workers specified by the workers parameter. If the workers parameter is
+// not positive, the number of logical CPUs is used. A password can be passed
+// in for encrypted input files. The search can be configured using the opts
+// parameter, the same way as for the Search function.
+// The results are returned in the order of the input files. The files which
+// cannot be searched do not stop the search. Instead, the encountered errors
+// are reported in the results of the files.
+func SearchFiles(inputPaths []string, text, password string, opts *SearchOpts, workers int) ([]*FileSearchResult, error) {
+	s, err := newSearcher(text, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(inputPaths) {
+		workers = len(inputPaths)
+	}
+
+	fileResults := make([]*FileSearchResult, len(inputPaths))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for idx := range indices {
+				inputPath := inputPaths[idx]
+				fileResult := &FileSearchResult{File: inputPath}
+
+				results, err := s.searchFile(inputPath, password)
+				if err != nil {
+					fileResult.Error = err.Error()
+				}
+				for _, result := range results {
+					fileResult.Occurrences += result.Occurrences
+				}
+				fileResult.Results = results
+
+				fileResults[idx] = fileResult
+			}
+		}()
+	}
+
+	for i := range inputPaths {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return fileResults, nil
+}
+
+// searcher finds the occurrences of a search term in text.
+type searcher struct {
+	re   *regexp.Regexp
+	opts SearchOpts
+}
+
+func newSearcher(text string, opts *SearchOpts) (*searcher, error) {
+	if opts == nil {
+		opts = &SearchOpts{}
+	}
+
+	pattern := text
+	if !opts.Regex {
+		if opts.NormalizeWhitespace {
+			text = strings.Join(strings.Fields(text), " ")
+		}
+		pattern = regexp.QuoteMeta(text)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &searcher{re: re, opts: *opts}, nil
+}
+
+// searchFile returns the occurrences of the search term in the PDF file
+// specified by the inputPath parameter.
+func (s *searcher) searchFile(inputPath, password string) ([]*SearchResult, error) {
 	// Read input file.
 	r, pages, _, _, err := readPDF(inputPath, password)
 	if err != nil {
@@ -41,26 +212,202 @@ func Search(inputPath, text, password string) ([]*SearchResult, error) {
 		}
 
 		// Extract page text.
-		extractor, err := uniextractor.New(page)
+		pageText, err := newPageText(page, numPage)
 		if err != nil {
 			return nil, err
 		}
 
-		pageText, err := extractor.ExtractText()
-		if err != nil {
-			return nil, err
+		matches := s.find(pageText.Text)
+		if len(matches) == 0 {
+			continue
 		}
 
-		occurrences := strings.Count(pageText, text)
-		if occurrences == 0 {
-			continue
+		for _, match := range matches {
+			match.BBoxes = pageText.rangeBBoxes(match.Offset, match.Length)
+			if s.opts.Context > 0 {
+				match.Snippet = snippet(pageText.Text, match.Offset, match.Length, s.opts.Context)
+			}
 		}
 
 		results = append(results, &SearchResult{
 			Page:        numPage,
-			Occurrences: occurrences,
+			Occurrences: len(matches),
+			Matches:     matches,
 		})
 	}
 
 	return results, nil
 }
+
+// find returns the occurrences of the search term in the provided text.
+func (s *searcher) find(text string) []*SearchMatch {
+	searchText := text
+	var offsets []int
+	if s.opts.NormalizeWhitespace {
+		searchText, offsets = normalizeText(text)
+	}
+
+	var matches []*SearchMatch
+	for _, loc := range s.re.FindAllStringIndex(searchText, -1) {
+		start, end := loc[0], loc[1]
+		if start == end {
+			continue
+		}
+		if s.opts.WholeWord && !isWholeWord(searchText, start, end) {
+			continue
+		}
+
+		match := &SearchMatch{
+			Text:   searchText[start:end],
+			Offset: start,
+			Length: end - start,
+		}
+		if offsets != nil {
+			match.Offset = offsets[start]
+			match.Length = offsets[end-1] + 1 - match.Offset
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches
+}
+
+// isWholeWord returns true if the specified range of the provided text is
+// not preceded or followed by a word character.
+func isWholeWord(text string, start, end int) bool {
+	isWordRune := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(r) {
+		return false
+	}
+
+	return true
+}
+
+// normalizeText collapses the consecutive whitespace characters of the
+// provided text into a single space and removes hyphenated line breaks.
+// The function also returns the offset in the original text of each byte
+// of the normalized text.
+func normalizeText(text string) (string, []int) {
+	var sb strings.Builder
+	offsets := make([]int, 0, len(text))
+
+	var prev rune
+	spaceOffset := -1
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+
+		// Remove hyphenated line breaks (e.g. "exam-\nple").
+		if r == '-' && unicode.IsLetter(prev) {
+			j := i + size
+			var lineBreak bool
+			for j < len(text) {
+				next, nextSize := utf8.DecodeRuneInString(text[j:])
+				if !unicode.IsSpace(next) {
+					break
+				}
+				lineBreak = lineBreak || next == '\n' || next == '\r'
+				j += nextSize
+			}
+
+			if next, _ := utf8.DecodeRuneInString(text[j:]); lineBreak && unicode.IsLower(next) {
+				i = j
+				continue
+			}
+		}
+
+		if unicode.IsSpace(r) {
+			if sb.Len() > 0 && spaceOffset < 0 {
+				spaceOffset = i
+			}
+			i += size
+			continue
+		}
+
+		if spaceOffset >= 0 {
+			sb.WriteByte(' ')
+			offsets = append(offsets, spaceOffset)
+			spaceOffset = -1
+		}
+
+		sb.WriteString(text[i : i+size])
+		for k := 0; k < size; k++ {
+			offsets = append(offsets, i+k)
+		}
+
+		prev = r
+		i += size
+	}
+
+	return sb.String(), offsets
+}
+
+// rangeBBoxes returns the bounding boxes of the text marks located in the
+// specified range of the page text. The marks are grouped by line.
+func (pt *PageText) rangeBBoxes(offset, length int) []Rect {
+	start := sort.Search(len(pt.Marks), func(i int) bool {
+		return pt.Marks[i].Offset >= offset
+	})
+	if start > 0 {
+		// Include the previous mark, if it overlaps the range.
+		if prev := pt.Marks[start-1]; prev.Offset+len(prev.Text) > offset {
+			start--
+		}
+	}
+
+	var bboxes []Rect
+	for _, mark := range pt.Marks[start:] {
+		if mark.Offset >= offset+length {
+			break
+		}
+		if mark.Meta || strings.TrimSpace(mark.Text) == "" {
+			continue
+		}
+
+		bboxes = appendLineBBox(bboxes, mark.BBox)
+	}
+
+	return bboxes
+}
+
+// appendLineBBox appends the provided bounding box to the specified ones.
+// If the bounding box is on the same line as the last one and follows it,
+// the two bounding boxes are merged instead.
+func appendLineBBox(bboxes []Rect, bbox Rect) []Rect {
+	if n := len(bboxes); n > 0 {
+		last := bboxes[n-1]
+		center := (bbox.Lly + bbox.Ury) / 2
+		height := math.Min(last.Height(), bbox.Height())
+		if center >= last.Lly && center <= last.Ury && bbox.Llx >= last.Urx-height {
+			bboxes[n-1] = last.union(bbox)
+			return bboxes
+		}
+	}
+
+	return append(bboxes, bbox)
+}
+
+// snippet returns the specified range of the provided text, along with the
+// specified number of characters of surrounding text. Whitespace characters
+// are collapsed into single spaces.
+func snippet(text string, offset, length, context int) string {
+	start := offset
+	for i := 0; i < context && start > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+
+	end := offset + length
+	for i := 0; i < context && end < len(text); i++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+
+	return normalizeSpaces(text[start:end])
+}
@@ -0,0 +1,297 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	unicreator "github.com/unidoc/unipdf/v4/creator"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// StampOpts represents the options used for stamping headers and footers
+// on the pages of PDF files.
+// The header and footer texts are templates which can contain the following
+// placeholders:
+//   - {page}: the current page number.
+//   - {total}: the total number of pages.
+//   - {date}: the current date. A Go time layout can be specified after
+//     a colon (e.g. {date:2006-01-02}).
+//   - {file}: the name of the input file.
+//   - {title}: the title of the input file.
+type StampOpts struct {
+	// HeaderLeft specifies the text drawn in the top-left corner of the page.
+	HeaderLeft string
+
+	// HeaderCenter specifies the text drawn in the top-center of the page.
+	HeaderCenter string
+
+	// HeaderRight specifies the text drawn in the top-right corner of the page.
+	HeaderRight string
+
+	// FooterLeft specifies the text drawn in the bottom-left corner of the page.
+	FooterLeft string
+
+	// FooterCenter specifies the text drawn in the bottom-center of the page.
+	FooterCenter string
+
+	// FooterRight specifies the text drawn in the bottom-right corner of
+	// the page.
+	FooterRight string
+
+	// FontName specifies the name of the standard font used for drawing
+	// the texts (default Helvetica).
+	FontName string
+
+	// FontPath specifies the path of a TrueType font file used for drawing
+	// the texts. If specified, the FontName field is ignored.
+	FontPath string
+
+	// FontSize specifies the font size of the texts (default 10).
+	FontSize float64
+
+	// Color specifies the color of the texts as a hex string
+	// (default #000000).
+	Color string
+
+	// Margin specifies the distance between the texts and the edges of
+	// the page. If nil, the default margin of 36 points is used.
+	Margin *float64
+
+	// SkipFirst specifies if the first page of the file is left intact.
+	SkipFirst bool
+}
+
+// Stamp draws the headers and footers specified by the opts parameter on the
+// pages of the PDF file specified by the inputPath parameter. A password can
+// be passed in for encrypted input files. The resulting file is saved at the
+// location specified by the outputPath parameter.
+// Also, a list of pages to stamp can be passed in. Every page that is not
+// included in the pages slice is left intact.
+// If the pages parameter is nil or an empty slice, all the pages of the input
+// file are stamped.
+func Stamp(inputPath, outputPath, password string, pages []int, opts *StampOpts) error {
+	if opts == nil {
+		return errors.New("must specify the stamp options")
+	}
+
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return err
+	}
+
+	// Prepare stamp.
+	stamp, err := newPageStamp(opts)
+	if err != nil {
+		return err
+	}
+
+	vars := stampVars{
+		total: pageCount,
+		file:  filepath.Base(inputPath),
+		date:  time.Now(),
+	}
+	if info, err := r.GetPdfInfo(); err == nil && info.Title != nil {
+		vars.title = info.Title.Decoded()
+	}
+
+	// Stamp pages.
+	c, err := stamp.apply(r, pageCount, pages, vars, nil)
+	if err != nil {
+		return err
+	}
+
+	// Add forms.
+	if r.AcroForm != nil {
+		c.SetForms(r.AcroForm)
+	}
+
+	// Write output file.
+	safe := inputPath == outputPath
+	return writeCreatorPDF(outputPath, c, safe)
+}
+
+// pageStamp contains the resources required for stamping headers and footers.
+type pageStamp struct {
+	opts     *StampOpts
+	font     *unipdf.PdfFont
+	fontSize float64
+	color    unicreator.Color
+	margin   float64
+}
+
+func newPageStamp(opts *StampOpts) (*pageStamp, error) {
+	font, err := loadFont(opts.FontName, opts.FontPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rgb, err := parseColor(opts.Color, [3]float64{0, 0, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	stamp := &pageStamp{
+		opts:     opts,
+		font:     font,
+		fontSize: opts.FontSize,
+		color:    unicreator.ColorRGBFromArithmetic(rgb[0], rgb[1], rgb[2]),
+		margin:   36,
+	}
+	if stamp.fontSize <= 0 {
+		stamp.fontSize = 10
+	}
+	if opts.Margin != nil {
+		if *opts.Margin < 0 {
+			return nil, errors.New("margin must not be negative")
+		}
+		stamp.margin = *opts.Margin
+	}
+
+	return stamp, nil
+}
+
+// apply adds the pages of the reader to a new creator and stamps the pages
+// specified by the pages parameter. If the pages parameter is empty, all the
+// pages are stamped. The optional update function is called before each
+// stamped page is drawn, in order to update the template variables.
+func (s *pageStamp) apply(r *unipdf.PdfReader, pageCount int, pages []int,
+	vars stampVars, update func(vars *stampVars)) (*unicreator.Creator, error) {
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
+
+	selectedPages := map[int]bool{}
+	for _, page := range pages {
+		selectedPages[page] = true
+	}
+
+	c := unicreator.New()
+	for i := 0; i < pageCount; i++ {
+		numPage := i + 1
+
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = c.AddPage(page); err != nil {
+			return nil, err
+		}
+
+		if !selectedPages[numPage] || (s.opts.SkipFirst && numPage == 1) {
+			continue
+		}
+
+		vars.page = numPage
+		if update != nil {
+			update(&vars)
+		}
+
+		if err = s.draw(c, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// draw draws the headers and footers on the current page of the creator,
+// using the provided template variables.
+func (s *pageStamp) draw(c *unicreator.Creator, vars stampVars) error {
+	ctx := c.Context()
+	pageWidth, pageHeight := ctx.PageWidth, ctx.PageHeight
+
+	texts := []struct {
+		template string
+		align    float64
+		footer   bool
+	}{
+		{s.opts.HeaderLeft, 0, false},
+		{s.opts.HeaderCenter, 0.5, false},
+		{s.opts.HeaderRight, 1, false},
+		{s.opts.FooterLeft, 0, true},
+		{s.opts.FooterCenter, 0.5, true},
+		{s.opts.FooterRight, 1, true},
+	}
+
+	for _, text := range texts {
+		if text.template == "" {
+			continue
+		}
+
+		p := c.NewParagraph(vars.expand(text.template))
+		p.SetFont(s.font)
+		p.SetFontSize(s.fontSize)
+		p.SetColor(s.color)
+		p.SetEnableWrap(false)
+
+		// Calculate text position.
+		width, height := p.Width(), p.Height()
+
+		x := s.margin + text.align*(pageWidth-2*s.margin-width)
+		y := s.margin
+		if text.footer {
+			y = pageHeight - s.margin - height
+		}
+		p.SetPos(x, y)
+
+		if err := c.Draw(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var templateRegexp = regexp.MustCompile(`\{(\w+)(?::([^}]+))?\}`)
+
+// stampVars contains the values of the placeholders which can be used in
+// stamp templates.
+type stampVars struct {
+	page  int
+	total int
+	file  string
+	title string
+	date  time.Time
+	bates string
+}
+
+// expand replaces the placeholders of the provided template with their values.
+// Unknown placeholders are left intact.
+func (v stampVars) expand(template string) string {
+	return templateRegexp.ReplaceAllStringFunc(template, func(match string) string {
+		groups := templateRegexp.FindStringSubmatch(match)
+
+		switch groups[1] {
+		case "page":
+			return strconv.Itoa(v.page)
+		case "total":
+			return strconv.Itoa(v.total)
+		case "file":
+			return v.file
+		case "title":
+			return v.title
+		case "bates":
+			if v.bates != "" {
+				return v.bates
+			}
+		case "date":
+			layout := groups[2]
+			if layout == "" {
+				layout = "2006-01-02"
+			}
+			return v.date.Format(layout)
+		}
+
+		return match
+	})
+}
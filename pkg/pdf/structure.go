@@ -0,0 +1,302 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"strings"
+	"unicode"
+
+	unicontent "github.com/unidoc/unipdf/v4/contentstream"
+	unicore "github.com/unidoc/unipdf/v4/core"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// structElement represents an element of the structure tree of a tagged
+// PDF file.
+type structElement struct {
+	// role is the standard structure type of the element (e.g. P, H1, Table).
+	role string
+
+	// actualText is the replacement text of the element, if specified.
+	actualText string
+
+	// kids contains the child elements and the marked-content references
+	// of the element, in logical order.
+	kids []structKid
+}
+
+// structKid represents a child of a structure element, which can be
+// either another structure element or a marked-content sequence.
+type structKid struct {
+	elem *structElement
+	page int64
+	mcid int
+}
+
+// parseStructTree parses the structure tree of the provided reader.
+// The function returns false if the file is not tagged.
+func parseStructTree(r *unipdf.PdfReader) (*structElement, bool) {
+	obj, found := r.GetCatalogStructTreeRoot()
+	if !found {
+		return nil, false
+	}
+
+	rootDict, ok := unicore.GetDict(obj)
+	if !ok {
+		return nil, false
+	}
+
+	// Parse role map.
+	roleMap := map[string]string{}
+	if roleDict, ok := unicore.GetDict(rootDict.Get("RoleMap")); ok {
+		for _, key := range roleDict.Keys() {
+			if role, ok := unicore.GetNameVal(roleDict.Get(key)); ok {
+				roleMap[string(key)] = role
+			}
+		}
+	}
+
+	parser := &structParser{
+		roleMap: roleMap,
+		visited: map[*unicore.PdfObjectDictionary]bool{},
+	}
+
+	root := &structElement{role: "Document"}
+	parser.parseKids(rootDict.Get("K"), root, 0)
+	if len(root.kids) == 0 {
+		return nil, false
+	}
+
+	return root, true
+}
+
+// structParser parses the elements of a structure tree.
+type structParser struct {
+	roleMap map[string]string
+	visited map[*unicore.PdfObjectDictionary]bool
+}
+
+// parseKids parses the kids of a structure element. The page parameter
+// is the object number of the page inherited from the parent element.
+func (p *structParser) parseKids(obj unicore.PdfObject, parent *structElement, page int64) {
+	obj = unicore.TraceToDirectObject(obj)
+
+	switch t := obj.(type) {
+	case *unicore.PdfObjectInteger:
+		parent.kids = append(parent.kids, structKid{page: page, mcid: int(*t)})
+	case *unicore.PdfObjectArray:
+		for _, kid := range t.Elements() {
+			p.parseKids(kid, parent, page)
+		}
+	case *unicore.PdfObjectDictionary:
+		if p.visited[t] {
+			return
+		}
+		p.visited[t] = true
+
+		if pg := objectNumber(t.Get("Pg")); pg != 0 {
+			page = pg
+		}
+
+		switch typ, _ := unicore.GetNameVal(t.Get("Type")); typ {
+		case "MCR":
+			if mcid, ok := unicore.GetIntVal(t.Get("MCID")); ok {
+				parent.kids = append(parent.kids, structKid{page: page, mcid: mcid})
+			}
+		case "OBJR":
+			// Object references (e.g. annotations) do not contain text.
+		default:
+			elem := &structElement{role: p.role(t)}
+			if text, ok := unicore.GetString(t.Get("ActualText")); ok {
+				elem.actualText = text.Decoded()
+			}
+
+			p.parseKids(t.Get("K"), elem, page)
+			parent.kids = append(parent.kids, structKid{elem: elem})
+		}
+	}
+}
+
+// role returns the standard structure type of the specified element.
+func (p *structParser) role(dict *unicore.PdfObjectDictionary) string {
+	role, _ := unicore.GetNameVal(dict.Get("S"))
+	for i := 0; i < 10; i++ {
+		mapped, ok := p.roleMap[role]
+		if !ok || mapped == role {
+			break
+		}
+		role = mapped
+	}
+
+	return role
+}
+
+// objectNumber returns the object number of the specified indirect object
+// or reference. If the object is direct, 0 is returned.
+func objectNumber(obj unicore.PdfObject) int64 {
+	switch t := obj.(type) {
+	case *unicore.PdfObjectReference:
+		return t.ObjectNumber
+	case *unicore.PdfIndirectObject:
+		return t.ObjectNumber
+	}
+
+	return 0
+}
+
+// markedContentText returns the text of each marked-content sequence of the
+// specified page, indexed by marked-content identifier (MCID).
+func markedContentText(page *unipdf.PdfPage) (map[int]string, error) {
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+
+	ops, err := unicontent.NewContentStreamParser(contents).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	res := page.Resources
+	fonts := map[string]*unipdf.PdfFont{}
+	texts := map[int]*strings.Builder{}
+
+	var font *unipdf.PdfFont
+	var mcids []int
+	var newLine bool
+
+	// writeText appends the provided text to the current marked-content
+	// sequence.
+	writeText := func(text string) {
+		mcid := -1
+		for i := len(mcids) - 1; i >= 0 && mcid < 0; i-- {
+			mcid = mcids[i]
+		}
+		if mcid < 0 || text == "" {
+			return
+		}
+
+		sb, ok := texts[mcid]
+		if !ok {
+			sb = &strings.Builder{}
+			texts[mcid] = sb
+		}
+
+		if newLine && sb.Len() > 0 {
+			str := sb.String()
+			last := []rune(str)[len([]rune(str))-1]
+			if !unicode.IsSpace(last) && !unicode.IsSpace([]rune(text)[0]) {
+				sb.WriteString(" ")
+			}
+		}
+		newLine = false
+
+		sb.WriteString(text)
+	}
+
+	// decode decodes the provided string operand using the current font.
+	decode := func(obj unicore.PdfObject) string {
+		data, ok := unicore.GetStringBytes(obj)
+		if !ok {
+			return ""
+		}
+		if font == nil {
+			return string(data)
+		}
+
+		text, _, _ := font.CharcodeBytesToUnicode(data)
+		return text
+	}
+
+	for _, op := range *ops {
+		switch op.Operand {
+		case "BDC":
+			mcid := -1
+			if len(op.Params) == 2 {
+				props, ok := unicore.GetDict(op.Params[1])
+				if !ok && res != nil {
+					if name, ok := unicore.GetName(op.Params[1]); ok {
+						if propsDict, ok := unicore.GetDict(res.Properties); ok {
+							props, _ = unicore.GetDict(propsDict.Get(*name))
+						}
+					}
+				}
+				if props != nil {
+					if val, ok := unicore.GetIntVal(props.Get("MCID")); ok {
+						mcid = val
+					}
+				}
+			}
+			mcids = append(mcids, mcid)
+		case "BMC":
+			mcids = append(mcids, -1)
+		case "EMC":
+			if len(mcids) > 0 {
+				mcids = mcids[:len(mcids)-1]
+			}
+		case "Tf":
+			if len(op.Params) != 2 || res == nil {
+				continue
+			}
+
+			name, ok := unicore.GetName(op.Params[0])
+			if !ok {
+				continue
+			}
+
+			var found bool
+			if font, found = fonts[string(*name)]; !found {
+				fontObj, ok := res.GetFontByName(*name)
+				if !ok {
+					font = nil
+					continue
+				}
+				if font, err = unipdf.NewPdfFontFromPdfObject(fontObj); err != nil {
+					font = nil
+				}
+				fonts[string(*name)] = font
+			}
+		case "BT", "Td", "TD", "T*", "Tm":
+			newLine = true
+		case "Tj":
+			if len(op.Params) == 1 {
+				writeText(decode(op.Params[0]))
+			}
+		case "'", "\"":
+			newLine = true
+			if len(op.Params) > 0 {
+				writeText(decode(op.Params[len(op.Params)-1]))
+			}
+		case "TJ":
+			if len(op.Params) != 1 {
+				continue
+			}
+
+			arr, ok := unicore.GetArray(op.Params[0])
+			if !ok {
+				continue
+			}
+
+			for _, obj := range arr.Elements() {
+				if offset, err := unicore.GetNumberAsFloat(obj); err == nil {
+					// Large negative offsets usually represent word spacing.
+					if offset < -250 {
+						writeText(" ")
+					}
+					continue
+				}
+				writeText(decode(obj))
+			}
+		}
+	}
+
+	result := make(map[int]string, len(texts))
+	for mcid, sb := range texts {
+		result[mcid] = sb.String()
+	}
+
+	return result, nil
+}
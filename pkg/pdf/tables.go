@@ -0,0 +1,133 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractTables detects the tables of the PDF file specified by the inputPath
+// parameter and writes each of them as a separate file, using the format
+// specified by the format parameter. Supported formats are csv and json.
+// JSON files contain the table as an array of rows, each row being an array
+// of cell texts. The files are named after the page number and the index of
+// the table on the page (e.g. p3_t1.csv).
+// The files are saved in a ZIP archive at the location specified by the
+// outputPath parameter, or in a TAR archive if the outputPath parameter has
+// the .tar, .tar.gz or .tgz extension. If the outputDir parameter is
+// specified, the files are saved in the output directory instead. If no
+// output location is specified, the files are saved in a ZIP archive in the
+// same directory as the input file.
+// A password can be passed in, if the input file is encrypted.
+// Also, a list of pages from which to extract tables can be passed in.
+// If the pages parameter is nil or an empty slice, the tables are extracted
+// from all the pages of the file.
+// The function returns the output location and the number of extracted
+// tables.
+func ExtractTables(inputPath, outputPath, outputDir, password string, pages []int,
+	format string) (string, int, error) {
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return "", 0, fmt.Errorf("unsupported table format: %s", format)
+	}
+
+	// Use input file directory if no output location is specified.
+	if outputDir != "" {
+		outputPath = outputDir
+	} else if outputPath == "" {
+		dir, name := filepath.Split(inputPath)
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + "_tables.zip"
+		outputPath = filepath.Join(dir, name)
+	}
+
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Extract tables.
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
+
+	var files []tableFile
+	for _, numPage := range pages {
+		// Get page.
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return "", 0, err
+		}
+
+		// Extract page tables.
+		pageText, err := newPageText(page, numPage)
+		if err != nil {
+			return "", 0, err
+		}
+
+		for i, table := range pageText.Tables {
+			data, err := encodeTable(table.Rows, format)
+			if err != nil {
+				return "", 0, err
+			}
+
+			files = append(files, tableFile{
+				name: fmt.Sprintf("p%d_t%d.%s", numPage, i+1, format),
+				data: data,
+			})
+		}
+	}
+
+	if len(files) == 0 {
+		return "", 0, nil
+	}
+
+	// Write output files.
+	fw, err := newFileWriter(outputPath, outputDir)
+	if err != nil {
+		return "", 0, err
+	}
+
+	for _, file := range files {
+		if err := writeFile(fw, file.name, file.data); err != nil {
+			fw.Close()
+			return "", 0, err
+		}
+	}
+
+	if err := fw.Close(); err != nil {
+		return "", 0, err
+	}
+
+	return outputPath, len(files), nil
+}
+
+// tableFile represents an encoded table.
+type tableFile struct {
+	name string
+	data []byte
+}
+
+// encodeTable encodes the provided table rows using the specified format.
+func encodeTable(rows [][]string, format string) ([]byte, error) {
+	if format == "json" {
+		return json.MarshalIndent(rows, "", "  ")
+	}
+
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return []byte(sb.String()), nil
+}
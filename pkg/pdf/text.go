@@ -0,0 +1,383 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pdf
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	uniextractor "github.com/unidoc/unipdf/v4/extractor"
+	unipdf "github.com/unidoc/unipdf/v4/model"
+)
+
+// Rect represents a rectangle in PDF coordinates (the origin is the
+// bottom-left corner of the page).
+type Rect struct {
+	Llx float64 `json:"llx"`
+	Lly float64 `json:"lly"`
+	Urx float64 `json:"urx"`
+	Ury float64 `json:"ury"`
+}
+
+// Width returns the width of the rectangle.
+func (r Rect) Width() float64 {
+	return r.Urx - r.Llx
+}
+
+// Height returns the height of the rectangle.
+func (r Rect) Height() float64 {
+	return r.Ury - r.Lly
+}
+
+// union returns the smallest rectangle containing both rectangles.
+func (r Rect) union(other Rect) Rect {
+	return Rect{
+		Llx: math.Min(r.Llx, other.Llx),
+		Lly: math.Min(r.Lly, other.Lly),
+		Urx: math.Max(r.Urx, other.Urx),
+		Ury: math.Max(r.Ury, other.Ury),
+	}
+}
+
+// intersects returns true if the rectangles overlap.
+func (r Rect) intersects(other Rect) bool {
+	return r.Llx < other.Urx && other.Llx < r.Urx && r.Lly < other.Ury && other.Lly < r.Ury
+}
+
+func newRect(r unipdf.PdfRectangle) Rect {
+	return Rect{Llx: r.Llx, Lly: r.Lly, Urx: r.Urx, Ury: r.Ury}
+}
+
+// TextMark represents a piece of text extracted from a page, usually a
+// single character, along with its position and style.
+type TextMark struct {
+	// Text is the text of the mark.
+	Text string `json:"text"`
+
+	// BBox is the bounding box of the mark.
+	BBox Rect `json:"bbox"`
+
+	// Font is the name of the font used to draw the mark.
+	Font string `json:"font,omitempty"`
+
+	// FontSize is the font size of the mark.
+	FontSize float64 `json:"font_size"`
+
+	// Color is the fill color of the mark as a hex string.
+	Color string `json:"color,omitempty"`
+
+	// Offset is the offset of the mark in the text of the page. The marks
+	// of a page are sorted in reading order.
+	Offset int `json:"offset"`
+
+	// Meta specifies if the mark is a space or a line break inserted by
+	// the text extractor.
+	Meta bool `json:"meta,omitempty"`
+}
+
+// TextLine represents a line of text extracted from a page.
+type TextLine struct {
+	// Text is the text of the line.
+	Text string `json:"text"`
+
+	// BBox is the bounding box of the line.
+	BBox Rect `json:"bbox"`
+
+	// Marks are the text marks of the line, in reading order.
+	Marks []TextMark `json:"-"`
+}
+
+// textWord represents a word of a text line.
+type textWord struct {
+	text string
+	bbox Rect
+}
+
+// words splits the line into words. The words are delimited by the
+// whitespace marks of the line.
+func (l TextLine) words() []textWord {
+	var words []textWord
+	var word *textWord
+
+	for _, mark := range l.Marks {
+		if strings.TrimSpace(mark.Text) == "" {
+			word = nil
+			continue
+		}
+
+		if word == nil {
+			words = append(words, textWord{bbox: mark.BBox})
+			word = &words[len(words)-1]
+		}
+
+		word.text += mark.Text
+		if !mark.Meta {
+			word.bbox = word.bbox.union(mark.BBox)
+		}
+	}
+
+	return words
+}
+
+// Table represents a table detected on a page.
+type Table struct {
+	// BBox is the bounding box of the table.
+	BBox Rect `json:"bbox"`
+
+	// Rows contains the cell texts of the table, grouped by row.
+	Rows [][]string `json:"rows"`
+}
+
+func newTable(table uniextractor.TextTable) Table {
+	rows := make([][]string, 0, len(table.Cells))
+	for _, cells := range table.Cells {
+		row := make([]string, 0, len(cells))
+		for _, cell := range cells {
+			row = append(row, strings.TrimSpace(cell.Text))
+		}
+		rows = append(rows, row)
+	}
+
+	return Table{BBox: newRect(table.PdfRectangle), Rows: rows}
+}
+
+// PageText contains the text of a page, along with the positional
+// information of its text marks, lines and tables.
+type PageText struct {
+	// Page is the page number.
+	Page int `json:"page"`
+
+	// BBox is the visible area of the page.
+	BBox Rect `json:"bbox"`
+
+	// Text is the text of the page, in reading order.
+	Text string `json:"text"`
+
+	// Lines are the text lines of the page, in reading order.
+	Lines []TextLine `json:"lines"`
+
+	// Marks are the text marks of the page, in reading order.
+	Marks []TextMark `json:"marks"`
+
+	// Tables are the tables detected on the page.
+	Tables []Table `json:"tables,omitempty"`
+}
+
+// newPageText extracts the text of the specified page.
+func newPageText(page *unipdf.PdfPage, numPage int) (*PageText, error) {
+	box, err := pageBox(page)
+	if err != nil {
+		return nil, err
+	}
+
+	extractor, err := uniextractor.New(page)
+	if err != nil {
+		return nil, err
+	}
+
+	pageText, _, _, err := extractor.ExtractPageText()
+	if err != nil {
+		return nil, err
+	}
+
+	pt := &PageText{
+		Page: numPage,
+		BBox: newRect(*box),
+		Text: pageText.Text(),
+	}
+
+	for _, mark := range pageText.Marks().Elements() {
+		tm := TextMark{
+			Text:     mark.Text,
+			BBox:     newRect(mark.BBox),
+			FontSize: mark.FontSize,
+			Color:    colorHex(mark.FillColor),
+			Offset:   mark.Offset,
+			Meta:     mark.Meta,
+		}
+		if mark.Font != nil {
+			tm.Font = mark.Font.BaseFont()
+		}
+
+		pt.Marks = append(pt.Marks, tm)
+	}
+	pt.Lines = textLines(pt.Marks)
+
+	for _, table := range pageText.Tables() {
+		pt.Tables = append(pt.Tables, newTable(table))
+	}
+
+	return pt, nil
+}
+
+// textLines groups the provided text marks into lines. The lines are
+// delimited by the line breaks inserted by the text extractor.
+func textLines(marks []TextMark) []TextLine {
+	var lines []TextLine
+	var line *TextLine
+
+	for _, mark := range marks {
+		if mark.Meta && strings.ContainsAny(mark.Text, "\r\n") {
+			line = nil
+			continue
+		}
+
+		if line == nil {
+			lines = append(lines, TextLine{})
+			line = &lines[len(lines)-1]
+		}
+
+		line.Text += mark.Text
+		line.Marks = append(line.Marks, mark)
+		if mark.Meta {
+			continue
+		}
+
+		if line.BBox == (Rect{}) {
+			line.BBox = mark.BBox
+		} else {
+			line.BBox = line.BBox.union(mark.BBox)
+		}
+	}
+
+	// Remove empty lines.
+	filtered := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line.Text) != "" {
+			filtered = append(filtered, line)
+		}
+	}
+
+	return filtered
+}
+
+// HasText returns true if the page contains any text. Pages without text
+// are usually scanned pages, which require OCR.
+func (pt *PageText) HasText() bool {
+	for _, mark := range pt.Marks {
+		if !mark.Meta && strings.TrimSpace(mark.Text) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PlainText returns the text of the page. If the layout parameter is true,
+// the physical layout of the page is preserved.
+func (pt *PageText) PlainText(layout bool) string {
+	if layout {
+		return pt.LayoutText()
+	}
+
+	return pt.Text
+}
+
+// LayoutText returns the text of the page, keeping the physical layout of
+// the page. The text marks are placed on a grid of characters, based on
+// their position on the page, which preserves columns and indentation.
+func (pt *PageText) LayoutText() string {
+	var marks []TextMark
+	var charWidth, charHeight float64
+	var numChars int
+
+	for _, mark := range pt.Marks {
+		text := strings.TrimSpace(mark.Text)
+		if mark.Meta || text == "" {
+			continue
+		}
+		marks = append(marks, mark)
+
+		runes := utf8.RuneCountInString(text)
+		charWidth += mark.BBox.Width()
+		charHeight += mark.BBox.Height() * float64(runes)
+		numChars += runes
+	}
+	if numChars == 0 {
+		return ""
+	}
+
+	charWidth /= float64(numChars)
+	charHeight /= float64(numChars)
+	if charWidth <= 0 {
+		charWidth = 5
+	}
+	if charHeight <= 0 {
+		charHeight = 10
+	}
+
+	// Group the text marks into rows, from top to bottom.
+	sort.SliceStable(marks, func(i, j int) bool {
+		return marks[i].BBox.Lly+marks[i].BBox.Ury > marks[j].BBox.Lly+marks[j].BBox.Ury
+	})
+
+	var rows [][]TextMark
+	var rowCenter float64
+	for _, mark := range marks {
+		center := (mark.BBox.Lly + mark.BBox.Ury) / 2
+		if len(rows) == 0 || math.Abs(rowCenter-center) > charHeight/2 {
+			rows = append(rows, nil)
+			rowCenter = center
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], mark)
+	}
+
+	// Place the text marks of each row on the grid.
+	var sb strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			// Preserve large vertical gaps between rows.
+			gap := rows[i-1][0].BBox.Lly - row[0].BBox.Ury
+			for n := int(gap / (charHeight * 1.5)); n > 0; n-- {
+				sb.WriteString("\n")
+			}
+		}
+
+		sort.SliceStable(row, func(i, j int) bool {
+			return row[i].BBox.Llx < row[j].BBox.Llx
+		})
+
+		var line []rune
+		var lastX float64
+		for j, mark := range row {
+			col := int(math.Round((mark.BBox.Llx - pt.BBox.Llx) / charWidth))
+			if col < 0 {
+				col = 0
+			}
+			if col <= len(line) {
+				col = len(line)
+				if j > 0 && mark.BBox.Llx-lastX > charWidth*0.3 {
+					col++
+				}
+			}
+
+			for len(line) < col {
+				line = append(line, ' ')
+			}
+			line = append(line, []rune(mark.Text)...)
+			lastX = mark.BBox.Urx
+		}
+
+		sb.WriteString(strings.TrimRight(string(line), " "))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// colorHex returns the hex representation of the specified color.
+func colorHex(c color.Color) string {
+	if c == nil {
+		return ""
+	}
+
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
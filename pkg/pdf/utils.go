@@ -7,9 +7,11 @@ package pdf
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	unicore "github.com/unidoc/unipdf/v4/core"
 	unisecurity "github.com/unidoc/unipdf/v4/core/security"
 	unicreator "github.com/unidoc/unipdf/v4/creator"
 	unipdf "github.com/unidoc/unipdf/v4/model"
@@ -253,3 +255,64 @@ func createPageRange(count int) []int {
 
 	return pages
 }
+
+// pageBox returns the visible area of the specified page. The crop box of
+// the page is used, if available. Otherwise, the media box is returned.
+func pageBox(page *unipdf.PdfPage) (*unipdf.PdfRectangle, error) {
+	if page.CropBox != nil {
+		return page.CropBox, nil
+	}
+
+	return page.GetMediaBox()
+}
+
+// addPageContents adds the provided content stream to the specified page.
+// If the under parameter is true, the content is drawn under the existing
+// page contents. Otherwise, it is drawn over them.
+func addPageContents(page *unipdf.PdfPage, content string, under bool) error {
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return err
+	}
+
+	if under {
+		contents = content + "\n" + contents
+	} else {
+		// Wrap the existing contents in order to reset the graphics state
+		// before drawing the new content.
+		contents = "q\n" + contents + "\nQ\n" + content
+	}
+
+	return page.SetContentStreams([]string{contents}, unicore.NewFlateEncoder())
+}
+
+// resourceName generates a resource name starting with the specified prefix,
+// which is not already used by the resource dictionary checked by the
+// exists function.
+func resourceName(prefix string, exists func(unicore.PdfObjectName) bool) unicore.PdfObjectName {
+	name := unicore.PdfObjectName(prefix)
+	for i := 1; exists(name); i++ {
+		name = unicore.PdfObjectName(fmt.Sprintf("%s%d", prefix, i))
+	}
+
+	return name
+}
+
+// copyStream returns a copy of the provided stream, having the specified
+// decoded data. The data of the copy is Flate encoded.
+func copyStream(stream *unicore.PdfObjectStream, data []byte) (*unicore.PdfObjectStream, error) {
+	copied, err := unicore.MakeStream(data, unicore.NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range stream.Keys() {
+		switch key {
+		case "Filter", "DecodeParms", "Length":
+			continue
+		}
+		copied.Set(key, stream.Get(key))
+	}
+
+	return copied, nil
+}
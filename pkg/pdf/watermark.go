@@ -6,9 +6,99 @@
 package pdf
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	unicontent "github.com/unidoc/unipdf/v4/contentstream"
+	unicore "github.com/unidoc/unipdf/v4/core"
 	unicreator "github.com/unidoc/unipdf/v4/creator"
+	unipdf "github.com/unidoc/unipdf/v4/model"
 )
 
+// WatermarkOpts represents the options used for adding watermarks to PDF files.
+type WatermarkOpts struct {
+	// Text specifies the text of the watermark. If empty, the image specified
+	// by the ImagePath field is used as the watermark.
+	Text string
+
+	// ImagePath specifies the path of the watermark image.
+	ImagePath string
+
+	// FontName specifies the name of the standard font used for text
+	// watermarks (default Helvetica).
+	FontName string
+
+	// FontPath specifies the path of a TrueType font file used for text
+	// watermarks. If specified, the FontName field is ignored.
+	FontPath string
+
+	// FontSize specifies the font size of text watermarks (default 48).
+	FontSize float64
+
+	// Color specifies the color of text watermarks as a hex string
+	// (default #808080).
+	Color string
+
+	// Position specifies the anchor position of the watermark on the page.
+	// Supported positions: center (default), top-left, top, top-right, left,
+	// right, bottom-left, bottom, bottom-right, custom.
+	Position string
+
+	// X and Y specify the center of the watermark, relative to the bottom-left
+	// corner of the page. Only used for the custom position.
+	X float64
+	Y float64
+
+	// Margin specifies the distance between the watermark and the edges of
+	// the page. Not used for the center and custom positions.
+	Margin float64
+
+	// Angle specifies the counter-clockwise rotation of the watermark,
+	// in degrees.
+	Angle float64
+
+	// Scale specifies the width of the watermark relative to the width of
+	// the page (0-1). If not specified, image watermarks span the full width
+	// of the page, while text watermarks use the configured font size.
+	Scale float64
+
+	// Opacity specifies the opacity of the watermark (0-1). If nil, the
+	// default opacity of 0.5 is used.
+	Opacity *float64
+
+	// Tile specifies if the watermark is repeated across the whole page.
+	Tile bool
+
+	// TileSpacing specifies the distance between tiled watermarks.
+	TileSpacing float64
+
+	// Under specifies if the watermark is drawn under the page contents.
+	// By default, the watermark is drawn over the page contents.
+	Under bool
+
+	// Layer specifies the name of the optional content group (layer) the
+	// watermark is added to. Layers can be toggled in PDF viewers.
+	// If empty, the watermark is not added to a layer, unless the Visibility
+	// field is specified, in which case the default layer name is used.
+	Layer string
+
+	// Visibility specifies when the watermark layer is visible.
+	// Supported values: all (default), print, screen.
+	Visibility string
+}
+
+// watermarkTag is the marked-content tag used for identifying the watermarks
+// added by the application. Also used as the prefix of the optional content
+// property resources of watermark layers.
+const watermarkTag = "UniPDFWatermark"
+
+// defaultWatermarkLayer is the name of the watermark optional content group,
+// used when no layer name is specified.
+const defaultWatermarkLayer = "Watermark"
+
 // Watermark adds the watermark image specified by the watermarkPath parameter
 // to the pages of the PDF file specified by the inputPath parameter.
 // A password can be passed in for encrypted input files.
@@ -19,64 +109,714 @@ import (
 // If the pages parameter is nil or an empty slice, all the pages of the input
 // file are watermarked.
 func Watermark(inputPath, outputPath, watermarkPath, password string, pages []int) error {
+	opts := &WatermarkOpts{ImagePath: watermarkPath}
+	return WatermarkWithOpts(inputPath, outputPath, password, pages, opts)
+}
+
+// WatermarkWithOpts adds a text or image watermark to the pages of the PDF
+// file specified by the inputPath parameter. The watermark is configured
+// using the opts parameter. A password can be passed in for encrypted input
+// files. The resulting file is saved at the location specified by the
+// outputPath parameter.
+// Also, a list of pages to add watermark to can be passed in. Every page that
+// is not included in the pages slice is left intact.
+// If the pages parameter is nil or an empty slice, all the pages of the input
+// file are watermarked.
+func WatermarkWithOpts(inputPath, outputPath, password string, pages []int, opts *WatermarkOpts) error {
+	if opts == nil || (opts.Text == "" && opts.ImagePath == "") {
+		return errors.New("must specify the watermark text or image")
+	}
+
 	// Read input file.
 	r, pageCount, _, _, err := readPDF(inputPath, password)
 	if err != nil {
 		return err
 	}
 
-	// Open watermark image.
-	c := unicreator.New()
-
-	watermark, err := c.NewImageFromFile(watermarkPath)
+	// Prepare watermark.
+	wm, err := newWatermark(opts)
 	if err != nil {
 		return err
 	}
 
-	// Add pages.
+	var ocProps *unicore.PdfObjectDictionary
+	if wm.ocg != nil {
+		if ocProps, err = addOCProperties(r, wm.ocg, wm.opts.Visibility); err != nil {
+			return err
+		}
+	}
+
+	// Add watermark to the selected pages.
 	if len(pages) == 0 {
 		pages = createPageRange(pageCount)
 	}
 
-	for i := 0; i < pageCount; i++ {
-		numPage := i + 1
+	for _, numPage := range pages {
+		if numPage < 1 || numPage > pageCount {
+			continue
+		}
 
 		page, err := r.GetPage(numPage)
 		if err != nil {
 			return err
 		}
 
-		var hasWatermark bool
-		for _, page := range pages {
-			if page == numPage {
-				hasWatermark = true
-				break
-			}
+		if err = wm.apply(page); err != nil {
+			return err
 		}
+	}
 
-		if err = c.AddPage(page); err != nil {
+	// Copy input file contents.
+	w := unipdf.NewPdfWriter()
+	if err := readerToWriter(r, &w, nil); err != nil {
+		return err
+	}
+	if ocProps != nil {
+		if err := w.SetOCProperties(ocProps); err != nil {
 			return err
 		}
+	}
+
+	// Write output file.
+	safe := inputPath == outputPath
+	return writePDF(outputPath, &w, safe)
+}
+
+// RemoveWatermarks removes the watermarks previously added by the application
+// from the PDF file specified by the inputPath parameter. The watermarks are
+// identified by their marked-content tag or by their optional content group.
+// Also, the content of the layer specified by the layer parameter is removed,
+// if a layer name is provided.
+// A password can be passed in for encrypted input files. The resulting file
+// is saved at the location specified by the outputPath parameter.
+// If the pages parameter is nil or an empty slice, the watermarks are removed
+// from all the pages of the input file. The watermark layers are only removed
+// from the optional content properties of the file if they are no longer
+// used by any of its pages.
+// The function returns the number of removed watermarks.
+func RemoveWatermarks(inputPath, outputPath, password string, pages []int, layer string) (int, error) {
+	// Read input file.
+	r, pageCount, _, _, err := readPDF(inputPath, password)
+	if err != nil {
+		return 0, err
+	}
+
+	// Remove watermarks from the selected pages.
+	if len(pages) == 0 {
+		pages = createPageRange(pageCount)
+	}
 
-		if !hasWatermark {
+	var count int
+	removedOCGs := map[unicore.PdfObject]struct{}{}
+	processed := map[int]bool{}
+	for _, numPage := range pages {
+		if numPage < 1 || numPage > pageCount || processed[numPage] {
 			continue
 		}
+		processed[numPage] = true
+
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return 0, err
+		}
 
-		watermark.ScaleToWidth(c.Context().PageWidth)
-		watermark.SetPos(0, (c.Context().PageHeight-watermark.Height())/2)
-		watermark.SetOpacity(0.5)
+		removed, err := removePageWatermarks(page, layer, removedOCGs)
+		if err != nil {
+			return 0, err
+		}
+		count += removed
+	}
 
-		if err = c.Draw(watermark); err != nil {
-			return err
+	// Keep the watermark layers which are still used by the pages that were
+	// not processed.
+	for numPage := 1; numPage <= pageCount && len(removedOCGs) > 0; numPage++ {
+		if processed[numPage] {
+			continue
+		}
+
+		page, err := r.GetPage(numPage)
+		if err != nil {
+			return 0, err
+		}
+		if page.Resources == nil {
+			continue
+		}
+
+		if props := getDict(page.Resources.Properties); props != nil {
+			for _, key := range props.Keys() {
+				delete(removedOCGs, props.Get(key))
+			}
 		}
 	}
 
-	// Add forms.
-	if r.AcroForm != nil {
-		c.SetForms(r.AcroForm)
+	// Remove unused watermark layers from the optional content properties.
+	if ocProps, err := r.GetOCProperties(); err == nil && len(removedOCGs) > 0 {
+		removeOCGs(ocProps, removedOCGs)
+	}
+
+	// Copy input file contents.
+	w := unipdf.NewPdfWriter()
+	if err := readerToWriter(r, &w, nil); err != nil {
+		return 0, err
 	}
 
 	// Write output file.
 	safe := inputPath == outputPath
-	return writeCreatorPDF(outputPath, c, safe)
+	return count, writePDF(outputPath, &w, safe)
+}
+
+// watermark contains the resources required for drawing a watermark.
+type watermark struct {
+	opts    *WatermarkOpts
+	opacity float64
+
+	// Text watermark resources.
+	font     *unipdf.PdfFont
+	encoded  []byte
+	fontSize float64
+	color    [3]float64
+
+	// Image watermark resources.
+	ximg *unipdf.XObjectImage
+	img  *unipdf.Image
+
+	// Optional content group of the watermark.
+	ocg *unicore.PdfIndirectObject
+}
+
+func newWatermark(opts *WatermarkOpts) (*watermark, error) {
+	if opts.Scale < 0 || opts.Scale > 1 {
+		return nil, errors.New("watermark scale must be between 0 and 1")
+	}
+	if _, ok := watermarkPositions[opts.Position]; !ok && opts.Position != "" {
+		return nil, fmt.Errorf("unsupported watermark position: %s", opts.Position)
+	}
+
+	wm := &watermark{opts: opts, opacity: 0.5}
+	if opts.Opacity != nil {
+		if *opts.Opacity < 0 || *opts.Opacity > 1 {
+			return nil, errors.New("watermark opacity must be between 0 and 1")
+		}
+		wm.opacity = *opts.Opacity
+	}
+
+	// Create watermark optional content group.
+	layer := opts.Layer
+	if layer == "" && opts.Visibility != "" && opts.Visibility != "all" {
+		layer = defaultWatermarkLayer
+	}
+	if layer != "" {
+		ocg, err := newOCG(layer, opts.Visibility)
+		if err != nil {
+			return nil, err
+		}
+		wm.ocg = ocg
+	}
+	if opts.Text != "" {
+		font, err := loadFont(opts.FontName, opts.FontPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if runes := unencodableRunes(font, opts.Text); len(runes) > 0 {
+			chars := make([]string, 0, len(runes))
+			for _, r := range runes {
+				chars = append(chars, fmt.Sprintf("%q (%U)", r, r))
+			}
+
+			return nil, fmt.Errorf("characters not supported by the watermark font: %s",
+				strings.Join(chars, ", "))
+		}
+
+		encoded, _ := font.StringToCharcodeBytes(opts.Text)
+		wm.font = font
+		wm.encoded = encoded
+
+		wm.fontSize = opts.FontSize
+		if wm.fontSize <= 0 {
+			wm.fontSize = 48
+		}
+
+		wm.color, err = parseColor(opts.Color, [3]float64{0.5, 0.5, 0.5})
+		if err != nil {
+			return nil, err
+		}
+
+		return wm, nil
+	}
+
+	// Load watermark image.
+	f, err := os.Open(opts.ImagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := unipdf.ImageHandling.Read(f)
+	if err != nil {
+		return nil, err
+	}
+
+	ximg, err := unipdf.NewXObjectImageFromImage(img, nil, unicore.NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	wm.img = img
+	wm.ximg = ximg
+
+	return wm, nil
+}
+
+// size returns the unrotated size of the watermark for a page of the
+// specified width.
+func (wm *watermark) size(pageWidth float64) (float64, float64) {
+	if wm.font != nil {
+		fontSize := wm.fontSize
+		width := textWidth(wm.font, wm.opts.Text, fontSize)
+		if wm.opts.Scale > 0 && width > 0 {
+			fontSize *= wm.opts.Scale * pageWidth / width
+			width = wm.opts.Scale * pageWidth
+		}
+
+		return width, fontSize
+	}
+
+	scale := wm.opts.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	width := scale * pageWidth
+	return width, width * float64(wm.img.Height) / float64(wm.img.Width)
+}
+
+// apply draws the watermark on the specified page.
+func (wm *watermark) apply(page *unipdf.PdfPage) error {
+	box, err := pageBox(page)
+	if err != nil {
+		return err
+	}
+	if page.Resources == nil {
+		page.Resources = unipdf.NewPdfPageResources()
+	}
+	res := page.Resources
+	opts := wm.opts
+
+	// Add watermark resources to the page.
+	gsName := resourceName("GSWatermark", res.HasExtGState)
+	extGState := unicore.MakeDict()
+	extGState.Set("ca", unicore.MakeFloat(wm.opacity))
+	extGState.Set("CA", unicore.MakeFloat(wm.opacity))
+	if err := res.AddExtGState(gsName, extGState); err != nil {
+		return err
+	}
+
+	var objName unicore.PdfObjectName
+	if wm.font != nil {
+		objName = resourceName("FWatermark", res.HasFontByName)
+		if err := res.SetFontByName(objName, wm.font.ToPdfObject()); err != nil {
+			return err
+		}
+	} else {
+		objName = resourceName("ImWatermark", res.HasXObjectByName)
+		if err := res.SetXObjectImageByName(objName, wm.ximg); err != nil {
+			return err
+		}
+	}
+
+	// Calculate the size of the watermark bounding box.
+	pageWidth, pageHeight := box.Width(), box.Height()
+	width, height := wm.size(pageWidth)
+
+	theta := opts.Angle * math.Pi / 180
+	sin, cos := math.Sin(theta), math.Cos(theta)
+	bboxWidth := math.Abs(width*cos) + math.Abs(height*sin)
+	bboxHeight := math.Abs(width*sin) + math.Abs(height*cos)
+
+	// Calculate watermark positions.
+	var centers [][2]float64
+	if opts.Tile {
+		stepX, stepY := bboxWidth+opts.TileSpacing, bboxHeight+opts.TileSpacing
+		if stepX <= 0 || stepY <= 0 {
+			return errors.New("invalid watermark tile size")
+		}
+
+		for y := stepY / 2; y-stepY/2 < pageHeight; y += stepY {
+			for x := stepX / 2; x-stepX/2 < pageWidth; x += stepX {
+				centers = append(centers, [2]float64{x, y})
+			}
+		}
+	} else {
+		x, y := anchorPosition(opts, pageWidth, pageHeight, bboxWidth, bboxHeight)
+		centers = append(centers, [2]float64{x, y})
+	}
+
+	// Generate watermark content stream. The watermark is marked using the
+	// watermark tag, in order to be able to identify it later.
+	cc := unicontent.NewContentCreator()
+	if wm.ocg != nil {
+		props := getDict(res.Properties)
+		if props == nil {
+			props = unicore.MakeDict()
+			res.Properties = props
+		}
+
+		propName := resourceName(watermarkTag, func(name unicore.PdfObjectName) bool {
+			return props.Get(name) != nil
+		})
+		props.Set(propName, wm.ocg)
+
+		cc.AddOperand(unicontent.ContentStreamOperation{
+			Operand: "BDC",
+			Params:  []unicore.PdfObject{unicore.MakeName("OC"), unicore.MakeName(string(propName))},
+		})
+	}
+	cc.Add_BMC(watermarkTag)
+	cc.Add_q()
+	cc.Add_gs(gsName)
+	for _, center := range centers {
+		cc.Add_q()
+		cc.Add_cm(1, 0, 0, 1, box.Llx+center[0], box.Lly+center[1])
+		cc.Add_cm(cos, sin, -sin, cos, 0, 0)
+		cc.Add_cm(1, 0, 0, 1, -width/2, -height/2)
+
+		if wm.font != nil {
+			fontSize := height
+			cc.Add_BT()
+			cc.Add_rg(wm.color[0], wm.color[1], wm.color[2])
+			cc.Add_Tf(objName, fontSize)
+			cc.Add_Td(0, 0.2*fontSize)
+			cc.Add_Tj(*unicore.MakeString(string(wm.encoded)))
+			cc.Add_ET()
+		} else {
+			cc.Add_cm(width, 0, 0, height, 0, 0)
+			cc.Add_Do(objName)
+		}
+		cc.Add_Q()
+	}
+	cc.Add_Q()
+	cc.Add_EMC()
+	if wm.ocg != nil {
+		cc.Add_EMC()
+	}
+
+	return addPageContents(page, cc.String(), opts.Under)
+}
+
+var watermarkPositions = map[string][2]float64{
+	"center":       {0.5, 0.5},
+	"top-left":     {0, 1},
+	"top":          {0.5, 1},
+	"top-right":    {1, 1},
+	"left":         {0, 0.5},
+	"right":        {1, 0.5},
+	"bottom-left":  {0, 0},
+	"bottom":       {0.5, 0},
+	"bottom-right": {1, 0},
+	"custom":       {},
+}
+
+// anchorPosition returns the center of a watermark having the specified
+// bounding box size, based on the position specified in the options.
+func anchorPosition(opts *WatermarkOpts, pageWidth, pageHeight, width, height float64) (float64, float64) {
+	if opts.Position == "custom" {
+		return opts.X, opts.Y
+	}
+
+	anchor, ok := watermarkPositions[opts.Position]
+	if !ok {
+		anchor = watermarkPositions["center"]
+	}
+
+	align := func(pos, size, pageSize float64) float64 {
+		switch pos {
+		case 0:
+			return opts.Margin + size/2
+		case 1:
+			return pageSize - opts.Margin - size/2
+		}
+
+		return pageSize / 2
+	}
+
+	return align(anchor[0], width, pageWidth), align(anchor[1], height, pageHeight)
+}
+
+// loadFont loads the TrueType font file specified by the fontPath parameter.
+// If no font file is specified, the standard font specified by the fontName
+// parameter is loaded instead.
+func loadFont(fontName, fontPath string) (*unipdf.PdfFont, error) {
+	if fontPath != "" {
+		return unipdf.NewCompositePdfFontFromTTFFile(fontPath)
+	}
+	if fontName == "" {
+		fontName = string(unipdf.HelveticaName)
+	}
+
+	return unipdf.NewStandard14Font(unipdf.StdFontName(fontName))
+}
+
+// textWidth returns the width of the specified text, drawn using the
+// provided font and font size.
+func textWidth(font *unipdf.PdfFont, text string, fontSize float64) float64 {
+	var width float64
+	for _, r := range text {
+		metrics, ok := font.GetRuneMetrics(r)
+		if !ok {
+			continue
+		}
+		width += metrics.Wx
+	}
+
+	return width * fontSize / 1000
+}
+
+// parseColor parses the provided hex color string. The default color is
+// returned if the color string is empty.
+func parseColor(hexColor string, def [3]float64) ([3]float64, error) {
+	if hexColor == "" {
+		return def, nil
+	}
+	if len(hexColor) != 7 || hexColor[0] != '#' {
+		return def, fmt.Errorf("invalid color: %s", hexColor)
+	}
+
+	r, g, b := unicreator.ColorRGBFromHex(hexColor).ToRGB()
+	return [3]float64{r, g, b}, nil
+}
+
+// newOCG creates a new optional content group having the specified name.
+// The visibility parameter specifies when the content of the group is
+// visible. Supported values: all (default), print, screen.
+func newOCG(name, visibility string) (*unicore.PdfIndirectObject, error) {
+	ocg := unicore.MakeDict()
+	ocg.Set("Type", unicore.MakeName("OCG"))
+	ocg.Set("Name", unicore.MakeString(name))
+
+	printState, viewState := "ON", "ON"
+	switch visibility {
+	case "", "all":
+		return unicore.MakeIndirectObject(ocg), nil
+	case "print":
+		viewState = "OFF"
+	case "screen":
+		printState = "OFF"
+	default:
+		return nil, fmt.Errorf("unsupported watermark visibility: %s", visibility)
+	}
+
+	printUsage := unicore.MakeDict()
+	printUsage.Set("PrintState", unicore.MakeName(printState))
+	viewUsage := unicore.MakeDict()
+	viewUsage.Set("ViewState", unicore.MakeName(viewState))
+
+	usage := unicore.MakeDict()
+	usage.Set("Print", printUsage)
+	usage.Set("View", viewUsage)
+	ocg.Set("Usage", usage)
+
+	return unicore.MakeIndirectObject(ocg), nil
+}
+
+// addOCProperties adds the provided optional content group to the optional
+// content properties of the specified reader. If the reader does not have
+// optional content properties, a new properties dictionary is created.
+func addOCProperties(r *unipdf.PdfReader, ocg *unicore.PdfIndirectObject, visibility string) (*unicore.PdfObjectDictionary, error) {
+	var ocProps *unicore.PdfObjectDictionary
+	if obj, err := r.GetOCProperties(); err == nil {
+		ocProps = getDict(obj)
+	}
+	if ocProps == nil {
+		ocProps = unicore.MakeDict()
+	}
+
+	// getArray returns the array with the specified key from the provided
+	// dictionary. The array is created if it does not exist.
+	getArray := func(dict *unicore.PdfObjectDictionary, key unicore.PdfObjectName) *unicore.PdfObjectArray {
+		arr, ok := unicore.GetArray(dict.Get(key))
+		if !ok {
+			arr = unicore.MakeArray()
+			dict.Set(key, arr)
+		}
+		return arr
+	}
+
+	// Register optional content group.
+	getArray(ocProps, "OCGs").Append(ocg)
+
+	// Set default viewing configuration.
+	config, ok := unicore.GetDict(ocProps.Get("D"))
+	if !ok {
+		config = unicore.MakeDict()
+		ocProps.Set("D", config)
+	}
+	getArray(config, "Order").Append(ocg)
+
+	if visibility == "print" {
+		getArray(config, "OFF").Append(ocg)
+	} else {
+		getArray(config, "ON").Append(ocg)
+	}
+
+	// Add usage application dictionaries, so that the viewers apply the
+	// print and view states specified by the group usage.
+	if visibility == "print" || visibility == "screen" {
+		as := getArray(config, "AS")
+		for _, event := range []string{"View", "Print"} {
+			app := unicore.MakeDict()
+			app.Set("Event", unicore.MakeName(event))
+			app.Set("OCGs", unicore.MakeArray(ocg))
+			app.Set("Category", unicore.MakeArray(unicore.MakeName(event)))
+			as.Append(app)
+		}
+	}
+
+	return ocProps, nil
+}
+
+// removePageWatermarks removes the watermarks from the content stream of the
+// specified page. The optional content groups of the removed watermarks are
+// added to the removedOCGs map. The function returns the number of removed
+// watermarks.
+func removePageWatermarks(page *unipdf.PdfPage, layer string, removedOCGs map[unicore.PdfObject]struct{}) (int, error) {
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return 0, err
+	}
+
+	ops, err := unicontent.NewContentStreamParser(contents).Parse()
+	if err != nil {
+		return 0, err
+	}
+
+	var props *unicore.PdfObjectDictionary
+	if page.Resources != nil {
+		props = getDict(page.Resources.Properties)
+	}
+
+	// Skip the marked-content sections identified as watermarks, along with
+	// any nested marked-content sections.
+	var count, depth int
+	var filtered unicontent.ContentStreamOperations
+	for _, op := range *ops {
+		switch op.Operand {
+		case "BMC", "BDC":
+			if depth > 0 {
+				depth++
+				continue
+			}
+			if isWatermarkSection(op, props, layer, removedOCGs) {
+				depth = 1
+				count++
+				continue
+			}
+		case "EMC":
+			if depth > 0 {
+				depth--
+				continue
+			}
+		default:
+			if depth > 0 {
+				continue
+			}
+		}
+
+		filtered = append(filtered, op)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	// Remove watermark optional content properties.
+	if props != nil {
+		for _, key := range props.Keys() {
+			if _, ok := removedOCGs[props.Get(key)]; ok {
+				props.Remove(key)
+			}
+		}
+	}
+
+	return count, page.SetContentStreams([]string{filtered.String()}, unicore.NewFlateEncoder())
+}
+
+// isWatermarkSection returns true if the marked-content section started by the
+// provided operation represents a watermark. The optional content group of
+// the watermark, if any, is added to the removedOCGs map.
+func isWatermarkSection(op *unicontent.ContentStreamOperation, props *unicore.PdfObjectDictionary,
+	layer string, removedOCGs map[unicore.PdfObject]struct{}) bool {
+	if len(op.Params) == 0 {
+		return false
+	}
+
+	tag, ok := unicore.GetName(op.Params[0])
+	if !ok {
+		return false
+	}
+	if string(*tag) == watermarkTag {
+		return true
+	}
+	if string(*tag) != "OC" || len(op.Params) < 2 || props == nil {
+		return false
+	}
+
+	// Check optional content group.
+	propName, ok := unicore.GetName(op.Params[1])
+	if !ok {
+		return false
+	}
+
+	ocgObj := props.Get(*propName)
+	ocg, ok := unicore.GetDict(ocgObj)
+	if !ok {
+		return false
+	}
+
+	ocgName, _ := unicore.GetStringVal(ocg.Get("Name"))
+	if !strings.HasPrefix(string(*propName), watermarkTag) && (layer == "" || ocgName != layer) {
+		return false
+	}
+
+	removedOCGs[ocgObj] = struct{}{}
+	return true
+}
+
+// removeOCGs removes the provided optional content groups from the specified
+// optional content properties.
+func removeOCGs(ocPropsObj unicore.PdfObject, ocgs map[unicore.PdfObject]struct{}) {
+	ocProps := getDict(ocPropsObj)
+	if ocProps == nil {
+		return
+	}
+
+	// filterArray removes the optional content groups from the array with
+	// the specified key of the provided dictionary.
+	filterArray := func(dict *unicore.PdfObjectDictionary, key unicore.PdfObjectName) {
+		arr, ok := unicore.GetArray(dict.Get(key))
+		if !ok {
+			return
+		}
+
+		var elems []unicore.PdfObject
+		for _, elem := range arr.Elements() {
+			if _, ok := ocgs[elem]; !ok {
+				elems = append(elems, elem)
+			}
+		}
+		dict.Set(key, unicore.MakeArray(elems...))
+	}
+
+	filterArray(ocProps, "OCGs")
+	if config, ok := unicore.GetDict(ocProps.Get("D")); ok {
+		for _, key := range []unicore.PdfObjectName{"Order", "ON", "OFF"} {
+			filterArray(config, key)
+		}
+
+		if as, ok := unicore.GetArray(config.Get("AS")); ok {
+			for _, app := range as.Elements() {
+				if appDict, ok := unicore.GetDict(app); ok {
+					filterArray(appDict, "OCGs")
+				}
+			}
+		}
+	}
 }